@@ -1,21 +1,26 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
-	"strings"
 
 	"career-schedule-api/internal/config"
 	"career-schedule-api/internal/database"
 	"career-schedule-api/internal/handlers"
+	"career-schedule-api/internal/jobs"
 	"career-schedule-api/internal/middleware"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/notify"
+	"career-schedule-api/internal/repository"
+	"career-schedule-api/internal/webhooks"
 
 	"time"
+	_ "time/tzdata" // 実行イメージに tzdata が無くてもタイムゾーンを解決できるようにする
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"golang.org/x/time/rate"
 	"gorm.io/gorm"
 )
 
@@ -29,6 +34,11 @@ func main() {
 	// Initialize configuration
 	cfg := config.New()
 
+	// `server migrate up|down|status` はマイグレーションのみ実行して終了する
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+
 	// Initialize database
 	var db *gorm.DB
 	log.Printf("DATABASE_URL configured: %t", cfg.DatabaseURL != "")
@@ -43,16 +53,16 @@ func main() {
 			log.Printf("Starting server without database connection for debugging...")
 			db = nil
 		} else {
-			// Auto-migrate tables
-			if err := database.Migrate(db); err != nil {
-				// テーブルが既に存在する場合は警告のみ
-				if strings.Contains(err.Error(), "already exists") {
-					log.Println("Database connected successfully (tables already exist)")
-				} else {
-					log.Printf("Warning: Failed to migrate database: %v", err)
+			log.Println("Database connected successfully")
+			if cfg.MigrateOnStart {
+				applied, err := database.Migrate(db)
+				for _, m := range applied {
+					log.Printf("Applied migration %s", m)
 				}
-			} else {
-				log.Println("Database connected and migrated successfully")
+				if err != nil {
+					log.Fatalf("Failed to migrate database: %v", err)
+				}
+				log.Printf("Database schema is up to date (%d migrations applied)", len(applied))
 			}
 		}
 	} else {
@@ -60,12 +70,108 @@ func main() {
 		db = nil
 	}
 
+	// メール送信（SMTP_HOST が未設定の場合はメール通知を使わない）
+	mailer := notify.SMTPNotifier{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.SMTPFrom,
+	}
+
+	// Background jobs
+	if db != nil {
+		scheduler := jobs.NewScheduler(db)
+		// すべての定期ジョブのスケジュールは SCHEDULE_TIMEZONE で解釈する
+		scheduleLoc, err := time.LoadLocation(cfg.ScheduleTimezone)
+		if err != nil {
+			log.Fatalf("Invalid SCHEDULE_TIMEZONE: %v", err)
+		}
+		if cfg.AutoArchiveSchedule != "off" {
+			schedule, err := jobs.ParseSchedule(cfg.AutoArchiveSchedule, scheduleLoc)
+			if err != nil {
+				log.Fatalf("Invalid AUTO_ARCHIVE_SCHEDULE: %v", err)
+			}
+			scheduler.Add(jobs.AutoArchiveJobName, schedule, jobs.ArchiveAllEvents)
+		}
+		if cfg.ReminderSchedule != "off" {
+			schedule, err := jobs.ParseSchedule(cfg.ReminderSchedule, scheduleLoc)
+			if err != nil {
+				log.Fatalf("Invalid REMINDER_SCHEDULE: %v", err)
+			}
+			notifiers := map[string]notify.Notifier{
+				models.ReminderChannelLog: notify.LogNotifier{},
+			}
+			if cfg.SMTPHost != "" {
+				notifiers[models.ReminderChannelEmail] = mailer
+			}
+			if cfg.ReminderWebhookURL != "" {
+				notifiers[models.ReminderChannelWebhook] = notify.NewWebhookNotifier(cfg.ReminderWebhookURL)
+			}
+			dispatcher := jobs.NewReminderDispatcher(db, notifiers)
+			scheduler.AddClaim(jobs.ReminderJobName, schedule, dispatcher.Claim)
+		}
+		if cfg.DigestSchedule != "off" {
+			if cfg.SMTPHost == "" {
+				log.Println("SMTP_HOST not set - daily digest emails are disabled")
+			} else {
+				schedule, err := jobs.ParseSchedule(cfg.DigestSchedule, scheduleLoc)
+				if err != nil {
+					log.Fatalf("Invalid DIGEST_SCHEDULE: %v", err)
+				}
+				scheduler.AddClaim(jobs.DigestJobName, schedule, jobs.NewDigestSender(db, mailer).Claim)
+			}
+		}
+		if cfg.WebhookSchedule != "off" {
+			schedule, err := jobs.ParseSchedule(cfg.WebhookSchedule, scheduleLoc)
+			if err != nil {
+				log.Fatalf("Invalid WEBHOOK_SCHEDULE: %v", err)
+			}
+			dispatcher := jobs.NewWebhookDispatcher(db, webhooks.NewClient(cfg.WebhookAllowPrivate))
+			scheduler.AddClaim(jobs.WebhookJobName, schedule, dispatcher.Claim)
+		}
+		if cfg.TrashPurgeSchedule != "off" {
+			schedule, err := jobs.ParseSchedule(cfg.TrashPurgeSchedule, scheduleLoc)
+			if err != nil {
+				log.Fatalf("Invalid TRASH_PURGE_SCHEDULE: %v", err)
+			}
+			retention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+			scheduler.Add(jobs.PurgeJobName, schedule, jobs.NewTrashPurger(retention).Run)
+		}
+		scheduler.Start(context.Background())
+	}
+
+	// JWT の検証（JWKS が設定されていれば非対称鍵、SUPABASE_JWT_SECRET があれば HS256 を受け付ける）
+	jwtVerifier := &middleware.JWTVerifier{
+		Secret:   cfg.SupabaseJWTSecret,
+		Audience: cfg.JWTAudience,
+		Issuer:   cfg.JWTIssuer,
+	}
+	if cfg.SupabaseJWKSURL != "" {
+		jwks := middleware.NewJWKS(cfg.SupabaseJWKSURL)
+		if err := jwks.Refresh(context.Background()); err != nil {
+			// 起動時に取得できなくても、トークンの検証時に取得し直す
+			log.Printf("Warning: %v", err)
+		}
+		jwks.Start(context.Background(), time.Duration(cfg.JWKSRefreshMinutes)*time.Minute)
+		jwtVerifier.JWKS = jwks
+	}
+
 	// Initialize Gin router
 	if cfg.GinMode == "release" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.Default()
 
+	// X-Forwarded-For を信頼するプロキシ（未設定なら接続元のアドレスをそのままクライアントのIPアドレスとする）
+	// レート制限・監査ログのIPアドレスを、クライアントが任意に指定できないようにする
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// リクエストID（監査ログとの突き合わせ用）
+	r.Use(middleware.RequestID())
+
 	// キャッシュ無効化（常に最新を取得）
 	r.Use(func(c *gin.Context) {
 		c.Header("Cache-Control", "no-store, no-cache, must-revalidate, proxy-revalidate")
@@ -88,26 +194,20 @@ func main() {
 	log.Printf("CORS AllowOrigins: %v", corsConfig.AllowOrigins)
 	log.Printf("FrontendURL: %s", cfg.FrontendURL)
 	log.Printf("ProductionFrontendURL: %s", cfg.ProductionFrontendURL)
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Cache-Control"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Cache-Control", middleware.RequestIDHeader}
+	corsConfig.ExposeHeaders = []string{middleware.RequestIDHeader, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
 	corsConfig.AllowCredentials = true
 	r.Use(cors.New(corsConfig))
 
-	// Rate limiting middleware (開発環境では緩和)
-	var limiter *rate.Limiter
-	if cfg.GinMode == "release" {
-		limiter = rate.NewLimiter(rate.Every(time.Minute), 100) // 本番環境: 100 requests per minute
-	} else {
-		limiter = rate.NewLimiter(rate.Every(time.Minute), 1000) // 開発環境: 1000 requests per minute
-	}
-	r.Use(func(c *gin.Context) {
-		if !limiter.Allow() {
-			c.JSON(429, gin.H{"error": "Rate limit exceeded"})
-			c.Abort()
-			return
-		}
-		c.Next()
+	// Rate limiting（認証済みならユーザーごと、未認証のルートはIPアドレスごと）
+	rateLimit := middleware.RateLimit(middleware.RateLimitConfig{
+		ReadsPerMinute:  cfg.RateLimitReadsPerMinute,
+		WritesPerMinute: cfg.RateLimitWritesPerMinute,
+		MaxKeys:         cfg.RateLimitMaxKeys,
 	})
+	// 認証前のIPアドレスごとの制限（認証に失敗するリクエストも数える）
+	ipRateLimit := middleware.IPRateLimit(cfg.RateLimitIPPerMinute, cfg.RateLimitMaxKeys)
 
 	// Security headers
 	r.Use(func(c *gin.Context) {
@@ -123,35 +223,122 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok", "service": "career-schedule-api"})
 	})
 
+	// Calendar subscription feed (public, authenticated by secret token)
+	r.GET("/calendar/feed/:token", rateLimit, handlers.CalendarFeed(db))
+
+	// 選考ステージの遷移ルール
+	stagePolicy := handlers.NewStagePolicy(cfg.StageAllowSkip)
+
+	// 予定確定時に作成するリマインダー
+	reminderDefaults := jobs.NewReminderDefaults(cfg.ReminderOffsets, cfg.ReminderChannel)
+
+	// 企業・予定のハンドラーはリポジトリ経由でデータベースを使う
+	var store repository.Store
+	if db != nil {
+		store = repository.NewGormStore(db)
+	}
+
 	// API routes
 	api := r.Group("/api/v1")
-	api.Use(middleware.Auth(cfg.SupabaseJWTSecret))
+	api.Use(ipRateLimit, middleware.Auth(jwtVerifier, db), rateLimit)
 	{
 		// Company routes
 		companies := api.Group("/companies")
 		{
-			companies.GET("", handlers.GetCompanies(db))
-			companies.POST("", handlers.CreateCompany(db))
-			companies.GET("/:id", handlers.GetCompany(db))
-			companies.PUT("/:id", handlers.UpdateCompany(db))
-			companies.DELETE("/:id", handlers.DeleteCompany(db))
-			companies.PUT("/:id/archive", handlers.ArchiveCompany(db))
-			companies.PUT("/:id/unarchive", handlers.UnarchiveCompany(db))
+			companies.GET("", handlers.GetCompanies(store))
+			companies.POST("", handlers.CreateCompany(store))
+			companies.GET("/:id", handlers.GetCompany(store))
+			companies.PUT("/:id", handlers.UpdateCompany(store, stagePolicy))
+			companies.DELETE("/:id", handlers.DeleteCompany(store))
+			companies.PUT("/:id/archive", handlers.ArchiveCompany(store))
+			companies.PUT("/:id/unarchive", handlers.UnarchiveCompany(store))
+			companies.GET("/:id/timeline", handlers.GetCompanyTimeline(db))
+			companies.GET("/:id/next-stages", handlers.GetCompanyNextStages(db, stagePolicy))
+		}
+
+		// Search route
+		api.GET("/search", handlers.Search(db))
+
+		// Audit log route
+		api.GET("/audit", handlers.GetAuditLogs(db))
+
+		// Trash routes
+		api.GET("/trash", handlers.GetTrash(db, cfg.TrashRetentionDays))
+		api.POST("/trash/:type/:id/restore", handlers.RestoreTrash(db))
+
+		// Admin routes
+		admin := api.Group("/admin")
+		admin.Use(middleware.RequireAdmin(cfg.AdminUserIDs))
+		{
+			admin.GET("/jobs/:name/runs", handlers.GetJobRuns(db))
+		}
+
+		// Statistics routes
+		stats := api.Group("/stats")
+		{
+			stats.GET("/funnel", handlers.GetFunnelStats(db))
+			stats.GET("/events", handlers.GetEventStats(db))
+		}
+
+		// Email template routes
+		emailTemplates := api.Group("/email-templates")
+		{
+			emailTemplates.GET("", handlers.GetEmailTemplates(db))
+			emailTemplates.POST("", handlers.CreateEmailTemplate(db))
+			emailTemplates.GET("/:id", handlers.GetEmailTemplate(db))
+			emailTemplates.PUT("/:id", handlers.UpdateEmailTemplate(db))
+			emailTemplates.DELETE("/:id", handlers.DeleteEmailTemplate(db))
+		}
+
+		// Webhook routes
+		webhookRoutes := api.Group("/webhooks")
+		{
+			webhookRoutes.GET("", handlers.GetWebhooks(db))
+			webhookRoutes.POST("", handlers.CreateWebhook(db))
+			webhookRoutes.PUT("/:id", handlers.UpdateWebhook(db))
+			webhookRoutes.DELETE("/:id", handlers.DeleteWebhook(db))
+			webhookRoutes.GET("/:id/deliveries", handlers.GetWebhookDeliveries(db))
+			webhookRoutes.POST("/:id/deliveries/:delivery_id/redeliver", handlers.RedeliverWebhook(db))
+		}
+
+		// User settings routes
+		me := api.Group("/me")
+		{
+			me.GET("/settings", handlers.GetSettings(db))
+			me.PUT("/settings", handlers.UpdateSettings(db))
+			me.GET("/calendar-feed", handlers.GetCalendarFeed(db))
+			// 購読URL（秘密のトークンを含む）の発行はログインセッションからのみ
+			me.POST("/calendar-feed", middleware.RequireSession(), handlers.CreateCalendarFeed(db, cfg.PublicAPIURL))
+			me.DELETE("/calendar-feed", handlers.DeleteCalendarFeed(db))
+			me.POST("/digest/send", handlers.SendDigest(db, mailer))
+
+			// 個人用アクセストークンの管理はログインセッションからのみ
+			apiTokens := me.Group("/api-tokens")
+			apiTokens.Use(middleware.RequireSession())
+			{
+				apiTokens.GET("", handlers.GetAPITokens(db))
+				apiTokens.POST("", handlers.CreateAPIToken(db))
+				apiTokens.DELETE("/:id", handlers.DeleteAPIToken(db))
+			}
 		}
 
 		// Event routes
 		events := api.Group("/events")
 		{
-			events.GET("", handlers.GetEvents(db))
-			events.POST("", handlers.CreateEvent(db))
-			events.GET("/:id", handlers.GetEvent(db))
-			events.PUT("/:id", handlers.UpdateEvent(db))
-			events.DELETE("/:id", handlers.DeleteEvent(db))
-			events.PUT("/:id/confirm", handlers.ConfirmEvent(db))
-			events.PUT("/:id/email-format", handlers.UpdateEventEmailFormat(db))
-			events.PUT("/:id/archive", handlers.ArchiveEvent(db))
-			events.PUT("/:id/unarchive", handlers.UnarchiveEvent(db))
-			events.PUT("/auto-archive/run", handlers.AutoArchiveEvents(db))
+			events.GET("", handlers.GetEvents(store))
+			events.POST("", handlers.CreateEvent(store))
+			events.POST("/conflicts", handlers.CheckEventConflicts(store))
+			events.POST("/import", handlers.ImportEvents(store, reminderDefaults))
+			events.GET("/:id", handlers.GetEvent(store))
+			events.PUT("/:id", handlers.UpdateEvent(store, reminderDefaults))
+			events.DELETE("/:id", handlers.DeleteEvent(store))
+			events.PUT("/:id/confirm", handlers.ConfirmEvent(store, reminderDefaults))
+			events.PUT("/:id/email-format", handlers.UpdateEventEmailFormat(store))
+			events.GET("/:id/email", handlers.RenderEventEmail(db))
+			events.GET("/:id/reminders", handlers.GetEventReminders(db))
+			events.PUT("/:id/archive", handlers.ArchiveEvent(store))
+			events.PUT("/:id/unarchive", handlers.UnarchiveEvent(store))
+			events.PUT("/auto-archive/run", handlers.AutoArchiveEvents(store))
 		}
 	}
 
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"career-schedule-api/internal/config"
+	"career-schedule-api/internal/database"
+	"career-schedule-api/internal/migrate"
+	"career-schedule-api/migrations"
+)
+
+const migrateUsage = "usage: server migrate up | down [N] | status"
+
+// runMigrate `server migrate` サブコマンド。終了コードを返す
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	if cfg.DatabaseURL == "" {
+		log.Println("DATABASE_URL not set")
+		return 1
+	}
+	db, err := database.New(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return 1
+	}
+	runner, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		log.Printf("Failed to load migrations: %v", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up()
+		for _, m := range applied {
+			fmt.Printf("applied  %s\n", m)
+		}
+		if err != nil {
+			log.Printf("Failed to migrate database: %v", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, migrateUsage)
+				return 2
+			}
+		}
+		reverted, err := runner.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %s\n", m)
+		}
+		if err != nil {
+			log.Printf("Failed to revert migration: %v", err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	case "status":
+		statuses, err := runner.Status()
+		if err != nil {
+			log.Printf("Failed to read migration status: %v", err)
+			return 1
+		}
+		for _, s := range statuses {
+			switch {
+			case s.Missing:
+				fmt.Printf("%03d  applied %s  (not included in this build)\n", s.Version, s.AppliedAt.Format("2006-01-02 15:04:05 MST"))
+			case s.AppliedAt != nil:
+				fmt.Printf("%s  applied %s\n", s, s.AppliedAt.Format("2006-01-02 15:04:05 MST"))
+			default:
+				fmt.Printf("%s  pending\n", s)
+			}
+		}
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+// Package apitoken は個人用アクセストークンの発行と照合を扱う
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"career-schedule-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Prefix 個人用アクセストークンの接頭辞（JWT と見分けるため、漏洩時に検出しやすくするため）
+const Prefix = "csat_"
+
+// displayLength 一覧に表示するトークンの先頭部分の長さ（接頭辞を含む）
+const displayLength = len(Prefix) + 6
+
+// lastUsedInterval 最終利用日時を更新する最短間隔（リクエストごとの書き込みを避けるため）
+const lastUsedInterval = time.Minute
+
+var (
+	// ErrInvalid 存在しない・失効したトークン
+	ErrInvalid = errors.New("invalid API token")
+	// ErrExpired 有効期限を過ぎたトークン
+	ErrExpired = errors.New("API token has expired")
+)
+
+// IsToken Authorization ヘッダーの値が個人用アクセストークンの形式かどうか
+func IsToken(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Generate 新しいトークンと、保存するハッシュ・表示用の先頭部分を返す
+func Generate() (token, hash, display string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	token = Prefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, Hash(token), token[:displayLength], nil
+}
+
+// Hash トークンは平文で保存せず SHA-256 のハッシュで照合する
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate トークンを照合して返し、最終利用日時を更新する
+func Authenticate(db *gorm.DB, token string) (*models.APIToken, error) {
+	var record models.APIToken
+	if err := db.Where("token_hash = ?", Hash(token)).First(&record).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalid
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if record.ExpiresAt != nil && !record.ExpiresAt.After(now) {
+		return nil, ErrExpired
+	}
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= lastUsedInterval {
+		if err := db.Model(&models.APIToken{}).Where("id = ?", record.ID).Update("last_used_at", now).Error; err != nil {
+			return nil, err
+		}
+		record.LastUsedAt = &now
+	}
+	return &record, nil
+}
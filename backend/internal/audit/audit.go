@@ -0,0 +1,167 @@
+// Package audit は変更操作を監査ログ（models.AuditLog）に記録する
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"career-schedule-api/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// 記録する操作
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionDelete    = "delete"
+	ActionArchive   = "archive"
+	ActionUnarchive = "unarchive"
+	ActionConfirm   = "confirm"
+	ActionRestore   = "restore"
+)
+
+// 記録する対象の種別
+const (
+	EntityCompany       = "company"
+	EntityEvent         = "event"
+	EntityEmailTemplate = "email_template"
+	EntityWebhook       = "webhook"
+	EntitySettings      = "user_settings"
+	EntityCalendarFeed  = "calendar_feed"
+	EntityAPIToken      = "api_token"
+)
+
+// Actor 変更を行ったユーザーとリクエスト
+type Actor struct {
+	UserID    string
+	RequestID string
+	ClientIP  string
+}
+
+// Change 1項目の変更前後の値（作成時の before、削除時の after は null）
+type Change struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// ignoredFields 変更のたびに必ず変わるため差分に含めない項目
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+	"sequence":   true,
+}
+
+var null = json.RawMessage("null")
+
+// Diff before / after を JSON にした時の項目ごとの差分（どちらかが nil なら全項目）
+// JSON に出ない項目（json:"-" のシークレットなど）は記録されない
+func Diff(before, after interface{}) (map[string]Change, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]bool{}
+	for k := range beforeFields {
+		keys[k] = true
+	}
+	for k := range afterFields {
+		keys[k] = true
+	}
+
+	changes := map[string]Change{}
+	for k := range keys {
+		if ignoredFields[k] && before != nil && after != nil {
+			continue
+		}
+		b, ok := beforeFields[k]
+		if !ok {
+			b = null
+		}
+		a, ok := afterFields[k]
+		if !ok {
+			a = null
+		}
+		if before != nil && after != nil && bytes.Equal(b, a) {
+			continue
+		}
+		changes[k] = Change{Before: b, After: a}
+	}
+	return changes, nil
+}
+
+func fields(v interface{}) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return map[string]json.RawMessage{}, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	// jsonb から読み込んだ値と比較できるよう空白を除く
+	for k, raw := range m {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err == nil {
+			m[k] = compact.Bytes()
+		}
+	}
+	return m, nil
+}
+
+// Record 変更と同じトランザクション（tx）で監査ログを1件追記する
+// 更新で差分が無い場合は記録しない
+func Record(tx *gorm.DB, actor Actor, entityType, entityID, action string, before, after interface{}) error {
+	return RecordAll(tx, []Entry{{Actor: actor, EntityType: entityType, EntityID: entityID, Action: action, Before: before, After: after}})
+}
+
+// Entry RecordAll で記録する1件
+type Entry struct {
+	Actor      Actor
+	EntityType string
+	EntityID   string
+	Action     string
+	Before     interface{}
+	After      interface{}
+}
+
+// RecordAll 一括で変更したものの監査ログを、同じトランザクション（tx）でまとめて追記する
+// 更新で差分が無いものは記録しない
+func RecordAll(tx *gorm.DB, entries []Entry) error {
+	logs := make([]models.AuditLog, 0, len(entries))
+	for _, entry := range entries {
+		changes, err := Diff(entry.Before, entry.After)
+		if err != nil {
+			return err
+		}
+		if entry.Action == ActionUpdate && len(changes) == 0 {
+			continue
+		}
+
+		body, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		logs = append(logs, models.AuditLog{
+			UserID:     entry.Actor.UserID,
+			EntityType: entry.EntityType,
+			EntityID:   entry.EntityID,
+			Action:     entry.Action,
+			Changes:    datatypes.JSON(body),
+			RequestID:  entry.Actor.RequestID,
+			ClientIP:   entry.Actor.ClientIP,
+		})
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	return tx.Create(&logs).Error
+}
@@ -1,28 +1,103 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+)
 
 type Config struct {
 	DatabaseURL           string
+	MigrateOnStart        bool
 	SupabaseURL           string
 	SupabaseAnonKey       string
 	SupabaseJWTSecret     string
+	SupabaseJWKSURL       string
+	JWKSRefreshMinutes    int
+	JWTAudience           string
+	JWTIssuer             string
 	Port                  string
 	GinMode               string
 	FrontendURL           string
 	ProductionFrontendURL string
+	PublicAPIURL          string
+	StageAllowSkip        bool
+	ScheduleTimezone      string // 定期ジョブの cron 形式のスケジュールを解釈するタイムゾーン
+	AutoArchiveSchedule   string
+	AdminUserIDs          []string
+	SMTPHost              string
+	SMTPPort              int
+	SMTPUsername          string
+	SMTPPassword          string
+	SMTPFrom              string
+	ReminderSchedule      string
+	ReminderOffsets       []int
+	ReminderChannel       string
+	ReminderWebhookURL    string
+	DigestSchedule        string
+	WebhookSchedule       string
+	WebhookAllowPrivate   bool
+	TrashPurgeSchedule    string
+	TrashRetentionDays    int
+
+	RateLimitReadsPerMinute  int
+	RateLimitWritesPerMinute int
+	RateLimitMaxKeys         int
+	RateLimitIPPerMinute     int // 認証前のIPアドレスごとの上限（不正なトークンの総当たり対策）
+	TrustedProxies           []string
 }
 
 func New() *Config {
+	supabaseURL := strings.TrimRight(getEnv("SUPABASE_URL", ""), "/")
+	// Supabase Auth が発行するトークンの iss（"off" で検証しない）
+	defaultIssuer := ""
+	if supabaseURL != "" {
+		defaultIssuer = supabaseURL + "/auth/v1"
+	}
+	jwtIssuer := getEnv("SUPABASE_JWT_ISSUER", defaultIssuer)
+	if jwtIssuer == "off" {
+		jwtIssuer = ""
+	}
+
 	return &Config{
 		DatabaseURL:           getEnv("DATABASE_URL", ""),
-		SupabaseURL:           getEnv("SUPABASE_URL", ""),
+		MigrateOnStart:        getEnvBool("MIGRATE_ON_START", true),
+		SupabaseURL:           supabaseURL,
 		SupabaseAnonKey:       getEnv("SUPABASE_ANON_KEY", ""),
 		SupabaseJWTSecret:     getEnv("SUPABASE_JWT_SECRET", ""),
+		SupabaseJWKSURL:       getEnv("SUPABASE_JWKS_URL", ""),
+		JWKSRefreshMinutes:    getEnvInt("SUPABASE_JWKS_REFRESH_MINUTES", 60),
+		JWTAudience:           getEnv("SUPABASE_JWT_AUDIENCE", "authenticated"),
+		JWTIssuer:             jwtIssuer,
 		Port:                  getEnv("PORT", "8080"),
 		GinMode:               getEnv("GIN_MODE", "debug"),
 		FrontendURL:           getEnv("FRONTEND_URL", "http://localhost:5173"),
 		ProductionFrontendURL: getEnv("PRODUCTION_FRONTEND_URL", ""),
+		PublicAPIURL:          getEnv("PUBLIC_API_URL", ""),
+		StageAllowSkip:        getEnvBool("STAGE_ALLOW_SKIP", true),
+		ScheduleTimezone:      getEnv("SCHEDULE_TIMEZONE", "Asia/Tokyo"),
+		AutoArchiveSchedule:   getEnv("AUTO_ARCHIVE_SCHEDULE", "5 * * * *"),
+		AdminUserIDs:          getEnvList("ADMIN_USER_IDS"),
+		SMTPHost:              getEnv("SMTP_HOST", ""),
+		SMTPPort:              getEnvInt("SMTP_PORT", 587),
+		SMTPUsername:          getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:          getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:              getEnv("SMTP_FROM", ""),
+		ReminderSchedule:      getEnv("REMINDER_SCHEDULE", "@every 1m"),
+		ReminderOffsets:       getEnvIntList("REMINDER_OFFSETS", []int{1440, 60}),
+		ReminderChannel:       getEnv("REMINDER_CHANNEL", "log"),
+		ReminderWebhookURL:    getEnv("REMINDER_WEBHOOK_URL", ""),
+		DigestSchedule:        getEnv("DIGEST_SCHEDULE", "*/15 * * * *"),
+		WebhookSchedule:       getEnv("WEBHOOK_SCHEDULE", "@every 1m"),
+		WebhookAllowPrivate:   getEnvBool("WEBHOOK_ALLOW_PRIVATE", false),
+		TrashPurgeSchedule:    getEnv("TRASH_PURGE_SCHEDULE", "30 3 * * *"),
+		TrashRetentionDays:    getEnvInt("TRASH_RETENTION_DAYS", 30),
+
+		RateLimitReadsPerMinute:  getEnvInt("RATE_LIMIT_READS_PER_MINUTE", 300),
+		RateLimitWritesPerMinute: getEnvInt("RATE_LIMIT_WRITES_PER_MINUTE", 60),
+		RateLimitMaxKeys:         getEnvInt("RATE_LIMIT_MAX_KEYS", 10000),
+		RateLimitIPPerMinute:     getEnvInt("RATE_LIMIT_IP_PER_MINUTE", 600),
+		TrustedProxies:           getEnvList("TRUSTED_PROXIES"),
 	}
 }
 
@@ -32,3 +107,45 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList カンマ区切りの値（空要素は除く）
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// getEnvIntList カンマ区切りの整数（未設定または解釈できない値を含む場合はデフォルト値）
+func getEnvIntList(key string, defaultValue []int) []int {
+	values := getEnvList(key)
+	if len(values) == 0 {
+		return defaultValue
+	}
+	ints := make([]int, 0, len(values))
+	for _, v := range values {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return defaultValue
+		}
+		ints = append(ints, n)
+	}
+	return ints
+}
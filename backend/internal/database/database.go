@@ -1,7 +1,8 @@
 package database
 
 import (
-	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/migrate"
+	"career-schedule-api/migrations"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -17,9 +18,6 @@ func New(databaseURL string) (*gorm.DB, error) {
 		// 本番環境でのログレベル調整
 		Logger: logger.Default.LogMode(logger.Error),
 
-		// コネクションプールの設定
-		DisableForeignKeyConstraintWhenMigrating: true,
-
 		// 本番環境でのPrepared Statement問題を回避
 		DisableNestedTransaction: true,
 	}
@@ -50,6 +48,11 @@ func New(databaseURL string) (*gorm.DB, error) {
 	return db, nil
 }
 
-func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(&models.Company{}, &models.Event{})
+// Migrate 未適用のマイグレーション（migrations/*.up.sql）をバージョン順に適用する
+func Migrate(db *gorm.DB) ([]migrate.Migration, error) {
+	runner, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	return runner.Up()
 }
@@ -0,0 +1,260 @@
+// Package digest は毎朝送る予定のダイジェスト（今日・明日の確定予定と、候補日の期限が近い予定）を作る
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"career-schedule-api/internal/emailtemplate"
+	"career-schedule-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ExpiringWithin 最後の候補日がこの期間内に過ぎてしまう予定を「期限が近い」とする
+const ExpiringWithin = 48 * time.Hour
+
+var stageLabels = map[string]string{
+	"entry":            "エントリー",
+	"document_review":  "書類選考",
+	"first_interview":  "一次面接",
+	"second_interview": "二次面接",
+	"final_interview":  "最終面接",
+	"offer":            "内定",
+	"rejected":         "不合格",
+}
+
+// Item ダイジェストに載せる予定1件
+type Item struct {
+	EventID     string     `json:"event_id"`
+	CompanyID   string     `json:"company_id"`
+	CompanyName string     `json:"company_name"`
+	Stage       string     `json:"stage"`
+	Title       string     `json:"title"`
+	Type        string     `json:"type"`
+	Location    string     `json:"location"`
+	IsOnline    bool       `json:"is_online"`
+	Start       time.Time  `json:"start_time"`
+	End         time.Time  `json:"end_time"`
+	Slots       []slot     `json:"remaining_slots,omitempty"` // 期限が近い予定の残りの候補日
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+type slot struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// Digest ユーザー1人分のダイジェスト
+type Digest struct {
+	Date     string `json:"date"` // ユーザーのタイムゾーンでの当日（YYYY-MM-DD）
+	Today    []Item `json:"today"`
+	Tomorrow []Item `json:"tomorrow"`
+	Expiring []Item `json:"expiring"`
+
+	loc   *time.Location
+	today time.Time
+}
+
+// Empty 載せる予定が1件も無い
+func (d *Digest) Empty() bool {
+	return len(d.Today) == 0 && len(d.Tomorrow) == 0 && len(d.Expiring) == 0
+}
+
+// Build now 時点のダイジェストを作る（アーカイブ済みの予定・企業は除く）
+func Build(db *gorm.DB, settings models.UserSettings, now time.Time) (*Digest, error) {
+	loc := settings.Location()
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	tomorrow := today.AddDate(0, 0, 1)
+	dayAfter := today.AddDate(0, 0, 2)
+
+	d := &Digest{
+		Date:     today.Format("2006-01-02"),
+		Today:    []Item{},
+		Tomorrow: []Item{},
+		Expiring: []Item{},
+		loc:      loc,
+		today:    today,
+	}
+
+	var confirmed []models.Event
+	if err := db.Where("user_id = ? AND is_archived = ? AND status = ? AND confirmed_slot IS NOT NULL", settings.UserID, false, "confirmed").
+		Where(models.ConfirmedStartExpr+" >= ? AND "+models.ConfirmedStartExpr+" < ?", today, dayAfter).
+		Order(models.ConfirmedStartExpr + " ASC").
+		Find(&confirmed).Error; err != nil {
+		return nil, err
+	}
+
+	var candidates []models.Event
+	if err := db.Where("user_id = ? AND is_archived = ? AND status = ?", settings.UserID, false, "candidate").
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	companies, err := loadCompanies(db, settings.UserID, append(confirmed, candidates...))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range confirmed {
+		company, ok := companies[event.CompanyID]
+		if !ok {
+			continue
+		}
+		var s slot
+		if err := json.Unmarshal(event.ConfirmedSlot, &s); err != nil || s.StartTime.IsZero() {
+			continue
+		}
+		item := newItem(event, company)
+		item.Start, item.End = s.StartTime, s.EndTime
+		if s.StartTime.Before(tomorrow) {
+			d.Today = append(d.Today, item)
+		} else {
+			d.Tomorrow = append(d.Tomorrow, item)
+		}
+	}
+
+	deadline := now.Add(ExpiringWithin)
+	for _, event := range candidates {
+		company, ok := companies[event.CompanyID]
+		if !ok || len(event.CandidateSlots) == 0 {
+			continue
+		}
+		var slots []slot
+		if err := json.Unmarshal(event.CandidateSlots, &slots); err != nil {
+			continue
+		}
+		remaining := []slot{}
+		for _, s := range slots {
+			if s.StartTime.After(now) {
+				remaining = append(remaining, s)
+			}
+		}
+		if len(remaining) == 0 {
+			continue
+		}
+		sort.Slice(remaining, func(i, j int) bool { return remaining[i].StartTime.Before(remaining[j].StartTime) })
+		last := remaining[len(remaining)-1].StartTime
+		if last.After(deadline) {
+			continue
+		}
+		item := newItem(event, company)
+		item.Slots = remaining
+		item.ExpiresAt = &last
+		d.Expiring = append(d.Expiring, item)
+	}
+	sort.SliceStable(d.Expiring, func(i, j int) bool { return d.Expiring[i].ExpiresAt.Before(*d.Expiring[j].ExpiresAt) })
+
+	return d, nil
+}
+
+// loadCompanies 予定の企業（アーカイブされていないもの）を ID で引けるようにする
+func loadCompanies(db *gorm.DB, userID string, events []models.Event) (map[string]models.Company, error) {
+	companies := map[string]models.Company{}
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.CompanyID)
+	}
+	if len(ids) == 0 {
+		return companies, nil
+	}
+
+	var rows []models.Company
+	if err := db.Where("user_id = ? AND is_archived = ? AND id IN ?", userID, false, ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, company := range rows {
+		companies[company.ID] = company
+	}
+	return companies, nil
+}
+
+// newItem 保存時にHTMLエスケープしているため戻して使う
+func newItem(event models.Event, company models.Company) Item {
+	return Item{
+		EventID:     event.ID,
+		CompanyID:   company.ID,
+		CompanyName: html.UnescapeString(company.Name),
+		Stage:       company.CurrentStage,
+		Title:       html.UnescapeString(event.Title),
+		Type:        event.Type,
+		Location:    html.UnescapeString(event.Location),
+		IsOnline:    event.IsOnline,
+	}
+}
+
+// Subject メールの件名 "【今日の予定】10/20(火) 確定2件・期限間近1件"
+func (d *Digest) Subject() string {
+	counts := []string{}
+	if n := len(d.Today) + len(d.Tomorrow); n > 0 {
+		counts = append(counts, fmt.Sprintf("確定%d件", n))
+	}
+	if n := len(d.Expiring); n > 0 {
+		counts = append(counts, fmt.Sprintf("期限間近%d件", n))
+	}
+	if len(counts) == 0 {
+		counts = append(counts, "予定なし")
+	}
+	return "【今日の予定】" + emailtemplate.FormatDate(d.today) + " " + strings.Join(counts, "・")
+}
+
+// Body メールの本文（プレーンテキスト）
+func (d *Digest) Body() string {
+	var b strings.Builder
+	section := func(heading string, items []Item) {
+		b.WriteString("■ " + heading + "\n")
+		if len(items) == 0 {
+			b.WriteString("予定はありません\n\n")
+			return
+		}
+		for _, item := range items {
+			b.WriteString("・" + emailtemplate.FormatSlot(emailtemplate.Slot{Start: item.Start, End: item.End}, d.loc) + " ")
+			b.WriteString(item.CompanyName + " " + item.Title)
+			if label, ok := stageLabels[item.Stage]; ok {
+				b.WriteString("（" + label + "）")
+			}
+			b.WriteString("\n")
+			if location := itemLocation(item); location != "" {
+				b.WriteString("　場所: " + location + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	section("今日 "+emailtemplate.FormatDate(d.today), d.Today)
+	section("明日 "+emailtemplate.FormatDate(d.today.AddDate(0, 0, 1)), d.Tomorrow)
+
+	b.WriteString("■ 候補日の期限が近い予定\n")
+	if len(d.Expiring) == 0 {
+		b.WriteString("予定はありません\n")
+	}
+	for _, item := range d.Expiring {
+		slots := make([]emailtemplate.Slot, 0, len(item.Slots))
+		for _, s := range item.Slots {
+			slots = append(slots, emailtemplate.Slot{Start: s.StartTime, End: s.EndTime})
+		}
+		b.WriteString("・" + item.CompanyName + " " + item.Title + "（最後の候補日 " +
+			emailtemplate.FormatDate(item.ExpiresAt.In(d.loc)) + " " + item.ExpiresAt.In(d.loc).Format("15:04") + "）\n")
+		for _, line := range strings.Split(emailtemplate.FormatSlots(slots, d.loc), "\n") {
+			b.WriteString("　" + line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+func itemLocation(item Item) string {
+	switch {
+	case item.Location != "":
+		return item.Location
+	case item.IsOnline:
+		return "オンライン"
+	default:
+		return ""
+	}
+}
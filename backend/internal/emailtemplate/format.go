@@ -0,0 +1,80 @@
+package emailtemplate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = [...]string{"日", "月", "火", "水", "木", "金", "土"}
+
+// Slot 日時の範囲
+type Slot struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FormatDate "10/20(火)" 形式
+func FormatDate(t time.Time) string {
+	return fmt.Sprintf("%d/%d(%s)", int(t.Month()), t.Day(), weekdays[t.Weekday()])
+}
+
+// FormatSlot "10/20(火) 10:00〜11:00" 形式
+func FormatSlot(s Slot, loc *time.Location) string {
+	start := s.Start.In(loc)
+	end := s.End.In(loc)
+	if sameDay(start, end) {
+		return fmt.Sprintf("%s %s〜%s", FormatDate(start), start.Format("15:04"), end.Format("15:04"))
+	}
+	return fmt.Sprintf("%s %s〜%s %s", FormatDate(start), start.Format("15:04"), FormatDate(end), end.Format("15:04"))
+}
+
+// FormatSlots 日付ごとにまとめた箇条書き
+//
+//	・10/20(火) 10:00〜11:00、13:00〜15:00
+//	・10/21(水) 09:00〜10:00
+func FormatSlots(slots []Slot, loc *time.Location) string {
+	var (
+		lines   []string
+		current string
+		times   []string
+	)
+	flush := func() {
+		if current != "" {
+			lines = append(lines, "・"+current+" "+strings.Join(times, "、"))
+		}
+	}
+	for _, s := range slots {
+		start := s.Start.In(loc)
+		end := s.End.In(loc)
+		date := FormatDate(start)
+		if date != current {
+			flush()
+			current = date
+			times = nil
+		}
+		if sameDay(start, end) {
+			times = append(times, start.Format("15:04")+"〜"+end.Format("15:04"))
+		} else {
+			times = append(times, start.Format("15:04")+"〜"+FormatDate(end)+" "+end.Format("15:04"))
+		}
+	}
+	flush()
+	return strings.Join(lines, "\n")
+}
+
+// FormatDuration "30分" / "1時間30分" 形式
+func FormatDuration(minutes int) string {
+	switch {
+	case minutes < 60:
+		return fmt.Sprintf("%d分", minutes)
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d時間", minutes/60)
+	default:
+		return fmt.Sprintf("%d時間%d分", minutes/60, minutes%60)
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
@@ -0,0 +1,121 @@
+// Package emailtemplate は日程調整メール用テンプレートの検証と展開を扱う
+//
+// テンプレートは本文中の {{placeholder}} を値で置き換えるだけのシンプルな形式で、
+// 使用できるプレースホルダーは Placeholders に列挙されたもののみ。
+package emailtemplate
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	openDelim  = "{{"
+	closeDelim = "}}"
+)
+
+// Placeholders 使用可能なプレースホルダーと説明
+var Placeholders = map[string]string{
+	"company_name":    "企業名",
+	"title":           "予定のタイトル",
+	"candidate_slots": "候補日時の一覧（日付ごと）",
+	"confirmed_slot":  "確定した日時",
+	"duration":        "所要時間",
+	"location":        "場所（オンラインの場合は「オンライン」）",
+}
+
+// DefaultTemplate カスタムフォーマット未設定時に使うテンプレート
+const DefaultTemplate = "以下の日程で調整可能です。\n{{candidate_slots}}"
+
+// Error テンプレートの構文エラー（位置は1始まり）
+type Error struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// segment テンプレートを分割した断片（name が空ならテキスト）
+type segment struct {
+	text string
+	name string
+}
+
+// parse テンプレートを断片に分割し、不正なプレースホルダーがあればエラーを返す
+func parse(tmpl string) ([]segment, error) {
+	var segments []segment
+	rest := tmpl
+	offset := 0
+	for {
+		start := strings.Index(rest, openDelim)
+		if start < 0 {
+			if end := strings.Index(rest, closeDelim); end >= 0 {
+				return nil, newError(tmpl, offset+end, `unexpected "}}" without matching "{{"`)
+			}
+			segments = append(segments, segment{text: rest})
+			return segments, nil
+		}
+		if end := strings.Index(rest[:start], closeDelim); end >= 0 {
+			return nil, newError(tmpl, offset+end, `unexpected "}}" without matching "{{"`)
+		}
+		segments = append(segments, segment{text: rest[:start]})
+
+		inner := rest[start+len(openDelim):]
+		end := strings.Index(inner, closeDelim)
+		if end < 0 {
+			return nil, newError(tmpl, offset+start, `unclosed placeholder, missing "}}"`)
+		}
+		if nested := strings.Index(inner[:end], openDelim); nested >= 0 {
+			return nil, newError(tmpl, offset+start, `unclosed placeholder, missing "}}"`)
+		}
+
+		name := strings.TrimSpace(inner[:end])
+		if name == "" {
+			return nil, newError(tmpl, offset+start, "empty placeholder")
+		}
+		if _, ok := Placeholders[name]; !ok {
+			return nil, newError(tmpl, offset+start, fmt.Sprintf("unknown placeholder %q", name))
+		}
+		segments = append(segments, segment{name: name})
+
+		consumed := start + len(openDelim) + end + len(closeDelim)
+		rest = rest[consumed:]
+		offset += consumed
+	}
+}
+
+// newError バイト位置を行・列（文字単位）に変換してエラーを作る
+func newError(tmpl string, pos int, msg string) *Error {
+	before := tmpl[:pos]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndex(before, "\n") + 1
+	column := len([]rune(before[lineStart:])) + 1
+	return &Error{Line: line, Column: column, Msg: msg}
+}
+
+// Validate テンプレートの構文を検証する
+func Validate(tmpl string) error {
+	_, err := parse(tmpl)
+	return err
+}
+
+// Render プレースホルダーを values の値で置き換える
+func Render(tmpl string, values map[string]string) (string, error) {
+	segments, err := parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, s := range segments {
+		if s.name == "" {
+			b.WriteString(s.text)
+			continue
+		}
+		b.WriteString(values[s.name])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetJobRuns バックグラウンドジョブの実行履歴（新しい順）
+// クエリ: limit（既定20、最大100）
+func GetJobRuns(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		jobName := c.Param("name")
+
+		limit := 20
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 100 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+				return
+			}
+			limit = n
+		}
+
+		var runs []models.JobRun
+		if err := db.Where("job_name = ?", jobName).
+			Order("started_at DESC").
+			Limit(limit).
+			Find(&runs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job runs"})
+			return
+		}
+
+		var lastRun *models.JobRun
+		if len(runs) > 0 {
+			lastRun = &runs[0]
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"job_name": jobName,
+			"last_run": lastRun,
+			"runs":     runs,
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"career-schedule-api/internal/apitoken"
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxAPITokensPerUser ユーザーごとに発行できる個人用アクセストークンの数
+const maxAPITokensPerUser = 20
+
+// maxAPITokenExpiryDays 有効期限として指定できる最大日数
+const maxAPITokenExpiryDays = 365
+
+// GetAPITokens 発行済みの個人用アクセストークン（トークン自体は返さない）
+func GetAPITokens(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var tokens []models.APIToken
+		if err := db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API tokens"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+	}
+}
+
+// CreateAPIToken 個人用アクセストークンの発行。トークンはこのレスポンスでのみ返す
+// scope は read（参照のみ）/ write（既定 read）、expires_in_days を省略すると無期限
+func CreateAPIToken(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var request struct {
+			Name          string `json:"name"`
+			Scope         string `json:"scope"`
+			ExpiresInDays *int   `json:"expires_in_days"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		token := models.APIToken{
+			UserID: userID,
+			Name:   strings.TrimSpace(request.Name),
+			Scope:  request.Scope,
+			Email:  c.GetString("user_email"),
+		}
+		if token.Scope == "" {
+			token.Scope = models.APITokenScopeRead
+		}
+		if request.ExpiresInDays != nil {
+			days := *request.ExpiresInDays
+			if days < 1 || days > maxAPITokenExpiryDays {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in_days must be between 1 and " + strconv.Itoa(maxAPITokenExpiryDays)})
+				return
+			}
+			expiresAt := time.Now().AddDate(0, 0, days)
+			token.ExpiresAt = &expiresAt
+		}
+
+		validate := validator.New()
+		if err := validate.Struct(&token); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+			return
+		}
+
+		var count int64
+		if err := db.Model(&models.APIToken{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count API tokens"})
+			return
+		}
+		if count >= maxAPITokensPerUser {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many API tokens (max " + strconv.Itoa(maxAPITokensPerUser) + ")"})
+			return
+		}
+
+		secret, hash, display, err := apitoken.Generate()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		token.TokenHash = hash
+		token.Prefix = display
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&token).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, auditActor(c), audit.EntityAPIToken, token.ID, audit.ActionCreate, nil, token)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"api_token": token,
+			"token":     secret,
+		})
+	}
+}
+
+// DeleteAPIToken 個人用アクセストークンを失効させる
+func DeleteAPIToken(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		tokenID := c.Param("id")
+
+		var rowsAffected int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var token models.APIToken
+			result := tx.Clauses(clause.Returning{}).Where("id = ? AND user_id = ?", tokenID, userID).Delete(&token)
+			if result.Error != nil {
+				return result.Error
+			}
+			rowsAffected = result.RowsAffected
+			if rowsAffected == 0 {
+				return nil
+			}
+			return audit.Record(tx, auditActor(c), audit.EntityAPIToken, tokenID, audit.ActionDelete, token, nil)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+			return
+		}
+
+		if rowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditActor 監査ログに記録するユーザーとリクエストの情報
+func auditActor(c *gin.Context) audit.Actor {
+	return audit.Actor{
+		UserID:    c.GetString("user_id"),
+		RequestID: c.GetString("request_id"),
+		ClientIP:  c.ClientIP(),
+	}
+}
+
+var auditSortKeys = map[string]repository.SortKey{
+	"created_at": {Expr: "created_at", Time: true},
+}
+
+// GetAuditLogs 自分の変更履歴（新しい順、常にページネーション形式）
+// クエリ: entity_id, entity_type, action で絞り込み、limit / cursor
+func GetAuditLogs(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		params, err := parseListParams(c, auditSortKeys, "created_at")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := db.Model(&models.AuditLog{}).Where("user_id = ?", userID)
+		if v := c.Query("entity_id"); v != "" {
+			query = query.Where("entity_id = ?", v)
+		}
+		if v := c.Query("entity_type"); v != "" {
+			query = query.Where("entity_type = ?", v)
+		}
+		if v := c.Query("action"); v != "" {
+			query = query.Where("action = ?", v)
+		}
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+			return
+		}
+		if query, err = params.ApplyCursor(query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var logs []models.AuditLog
+		if err := query.Order(params.OrderClause()).Limit(params.Limit + 1).Find(&logs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit logs"})
+			return
+		}
+
+		response := pageResponse{Items: logs, Total: total}
+		if len(logs) > params.Limit {
+			logs = logs[:params.Limit]
+			last := logs[len(logs)-1]
+			next := encodeCursor(cursorValue(last.CreatedAt), last.ID)
+			response.Items = logs
+			response.NextCursor = &next
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
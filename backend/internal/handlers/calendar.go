@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"html"
+	"net/http"
+	"strings"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/ical"
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// calendarFeedPath 購読URLのパス（トークンは末尾に付与）
+const calendarFeedPath = "/calendar/feed/"
+
+// generateToken URLセーフなランダムトークンを生成
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken トークンは平文で保存せず SHA-256 のハッシュで照合する
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestBaseURL 設定がなければリクエストからAPIのベースURLを組み立てる
+func requestBaseURL(c *gin.Context, publicURL string) string {
+	if publicURL != "" {
+		return strings.TrimRight(publicURL, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// GetCalendarFeed 購読URLの発行状況
+func GetCalendarFeed(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var feed models.CalendarFeedToken
+		if err := db.Where("user_id = ?", userID).First(&feed).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusOK, gin.H{"enabled": false})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar feed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"enabled": true, "created_at": feed.CreatedAt})
+	}
+}
+
+// CreateCalendarFeed 購読URLを発行（既存のURLは無効化される）
+// トークンはハッシュのみ保存するため、URLはこのレスポンスでのみ返す
+func CreateCalendarFeed(db *gorm.DB, publicURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		token, err := generateToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+
+		feed := models.CalendarFeedToken{UserID: userID, TokenHash: hashToken(token)}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("user_id = ?", userID).Delete(&models.CalendarFeedToken{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(&feed).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, auditActor(c), audit.EntityCalendarFeed, feed.ID, audit.ActionCreate, nil, feed)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"url":        requestBaseURL(c, publicURL) + calendarFeedPath + token + ".ics",
+			"created_at": feed.CreatedAt,
+		})
+	}
+}
+
+// DeleteCalendarFeed 購読URLを無効化
+func DeleteCalendarFeed(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var rowsAffected int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var feed models.CalendarFeedToken
+			result := tx.Clauses(clause.Returning{}).Where("user_id = ?", userID).Delete(&feed)
+			if result.Error != nil {
+				return result.Error
+			}
+			rowsAffected = result.RowsAffected
+			if rowsAffected == 0 {
+				return nil
+			}
+			return audit.Record(tx, auditActor(c), audit.EntityCalendarFeed, feed.ID, audit.ActionDelete, feed, nil)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke calendar feed"})
+			return
+		}
+
+		if rowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked successfully"})
+	}
+}
+
+// CalendarFeed 確定済み・未アーカイブの予定を iCalendar 形式で配信（トークン認証、公開ルート）
+func CalendarFeed(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+		var feed models.CalendarFeedToken
+		if err := db.Where("token_hash = ?", hashToken(token)).First(&feed).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Calendar feed not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar feed"})
+			return
+		}
+
+		settings, err := loadUserSettings(db, feed.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+
+		var events []models.Event
+		if err := db.Where("user_id = ? AND status = ? AND is_archived = ? AND confirmed_slot IS NOT NULL", feed.UserID, "confirmed", false).
+			Order("created_at ASC").
+			Find(&events).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+			return
+		}
+
+		calendar := ical.Calendar{Name: "就活スケジュール", Timezone: settings.Location().String()}
+		for _, event := range events {
+			var confirmed timeSlot
+			if err := json.Unmarshal(event.ConfirmedSlot, &confirmed); err != nil || !confirmed.valid() {
+				continue
+			}
+			calendar.Events = append(calendar.Events, toICalEvent(event, confirmed))
+		}
+
+		c.Header("Content-Type", "text/calendar; charset=utf-8")
+		c.Header("Content-Disposition", `inline; filename="career-schedule.ics"`)
+		c.Status(http.StatusOK)
+		if err := calendar.Encode(c.Writer); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// toICalEvent 予定を VEVENT に変換（保存時にHTMLエスケープした値は元に戻す）
+func toICalEvent(event models.Event, slot timeSlot) ical.Event {
+	companyName := html.UnescapeString(event.CompanyName)
+	location := html.UnescapeString(event.Location)
+	if location == "" && event.IsOnline {
+		location = "オンライン"
+	}
+
+	description := "企業: " + companyName
+	if event.IsOnline {
+		description += "\n形式: オンライン"
+	}
+	if event.Notes != "" {
+		description += "\n\n" + html.UnescapeString(event.Notes)
+	}
+
+	return ical.Event{
+		UID:          event.ID + "@career-schedule-api",
+		Sequence:     event.Sequence,
+		Summary:      "【" + companyName + "】" + html.UnescapeString(event.Title),
+		Location:     location,
+		Description:  description,
+		Status:       "CONFIRMED",
+		Start:        slot.StartTime,
+		End:          slot.EndTime,
+		Created:      event.CreatedAt,
+		LastModified: event.UpdatedAt,
+	}
+}
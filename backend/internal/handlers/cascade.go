@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+	"career-schedule-api/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 企業の削除・アーカイブに合わせて、その企業の予定にも同じ操作を行う
+// 予定には企業と同じ日時（deleted_at / archived_at）を記録し、企業を元に戻す時はその日時の予定だけを戻す
+// （企業より前に個別に削除・アーカイブした予定はそのまま）
+
+// trashCompanyEvents 企業と一緒に予定をゴミ箱へ移動し、件数を返す
+func trashCompanyEvents(tx repository.Store, actor audit.Actor, company models.Company) (int, error) {
+	events, err := tx.Events().List(company.UserID, repository.EventFilter{CompanyID: company.ID}, repository.Page{})
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		event.DeletedAt = company.DeletedAt
+		if err := tx.Events().Save(&event); err != nil {
+			return 0, err
+		}
+		if err := tx.Recorder().Audit(actor, audit.EntityEvent, event.ID, audit.ActionDelete, event, nil); err != nil {
+			return 0, err
+		}
+		if err := tx.Recorder().Webhook(company.UserID, webhooks.EventDeleted, gin.H{"id": event.ID}); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+// restoreCompanyEvents 企業と一緒にゴミ箱へ移動した予定を戻し、件数を返す
+func restoreCompanyEvents(tx *gorm.DB, actor audit.Actor, company models.Company, deletedAt time.Time) (int, error) {
+	var events []models.Event
+	if err := tx.Unscoped().Where("company_id = ? AND user_id = ? AND deleted_at = ?", company.ID, company.UserID, deletedAt).Find(&events).Error; err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		before := event
+		event.DeletedAt = gorm.DeletedAt{}
+		if err := tx.Unscoped().Save(&event).Error; err != nil {
+			return 0, err
+		}
+		if err := audit.Record(tx, actor, audit.EntityEvent, event.ID, audit.ActionRestore, before, event); err != nil {
+			return 0, err
+		}
+		if err := webhooks.Enqueue(tx, company.UserID, webhooks.EventRestored, event); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+// archiveCompanyEvents 企業と一緒に未アーカイブの予定をアーカイブし、件数を返す
+func archiveCompanyEvents(tx repository.Store, actor audit.Actor, company models.Company) (int, error) {
+	notArchived := false
+	events, err := tx.Events().List(company.UserID, repository.EventFilter{CompanyID: company.ID, IsArchived: &notArchived}, repository.Page{})
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		before := event
+		event.IsArchived = true
+		event.ArchivedAt = company.ArchivedAt
+		if err := tx.Events().Save(&event); err != nil {
+			return 0, err
+		}
+		if err := tx.Recorder().Audit(actor, audit.EntityEvent, event.ID, audit.ActionArchive, before, event); err != nil {
+			return 0, err
+		}
+		if err := tx.Recorder().Webhook(company.UserID, webhooks.EventArchived, event); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+// unarchiveCompanyEvents 企業と一緒にアーカイブした予定を戻し、件数を返す
+func unarchiveCompanyEvents(tx repository.Store, actor audit.Actor, company models.Company, archivedAt time.Time) (int, error) {
+	archived := true
+	events, err := tx.Events().List(company.UserID, repository.EventFilter{CompanyID: company.ID, IsArchived: &archived, ArchivedAt: &archivedAt}, repository.Page{})
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		before := event
+		event.IsArchived = false
+		event.ArchivedAt = nil
+		if err := tx.Events().Save(&event); err != nil {
+			return 0, err
+		}
+		if err := tx.Recorder().Audit(actor, audit.EntityEvent, event.ID, audit.ActionUnarchive, before, event); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
@@ -6,39 +6,84 @@ import (
 	"strings"
 	"time"
 
+	"career-schedule-api/internal/audit"
 	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+	"career-schedule-api/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
 )
 
-func GetCompanies(db *gorm.DB) gin.HandlerFunc {
+// GetCompanies 企業一覧
+// クエリ: is_archived, current_stage, industry で絞り込み、sort / order で並び替え
+// limit / cursor を指定した場合はページネーション形式で返す
+func GetCompanies(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 
 		userID := c.GetString("user_id")
 
-		var companies []models.Company
-		// クエリ最適化: 必要なフィールドのみ選択、インデックス活用
-		if err := db.Select("id, user_id, name, industry, position, current_stage, notes, is_archived, archived_at, created_at, updated_at").
-			Where("user_id = ?", userID).
-			Order("updated_at DESC"). // 最新更新順でソート
-			Find(&companies).Error; err != nil {
+		params, err := parseListParams(c, repository.CompanySortKeys, "updated_at")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		isArchived, err := parseBoolQuery(c, "is_archived")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		filter := repository.CompanyFilter{
+			IsArchived:   isArchived,
+			CurrentStage: c.Query("current_stage"),
+			Industry:     c.Query("industry"),
+		}
+
+		var total int64
+		page := params.Page
+		page.Limit = 0
+		if params.Paginated {
+			if total, err = store.Companies().Count(userID, filter); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch companies"})
+				return
+			}
+			page.Limit = params.Limit + 1
+		} else {
+			page.Cursor = nil
+		}
+
+		companies, err := store.Companies().List(userID, filter, page)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch companies"})
 			return
 		}
 
-		c.JSON(http.StatusOK, companies)
+		if !params.Paginated {
+			c.JSON(http.StatusOK, companies)
+			return
+		}
+
+		response := pageResponse{Items: companies, Total: total}
+		if len(companies) > params.Limit {
+			companies = companies[:params.Limit]
+			last := companies[len(companies)-1]
+			next := encodeCursor(cursorValue(repository.CompanySortValue(last, params.SortName)), last.ID)
+			response.Items = companies
+			response.NextCursor = &next
+		}
+		c.JSON(http.StatusOK, response)
 	}
 }
 
-func CreateCompany(db *gorm.DB) gin.HandlerFunc {
+func CreateCompany(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
@@ -65,8 +110,22 @@ func CreateCompany(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		company.UserID = userID
+		company.DeletedAt = gorm.DeletedAt{}
 
-		if err := db.Create(&company).Error; err != nil {
+		// 企業の登録と初期ステージの履歴を同時に保存
+		err := store.Transaction(func(tx repository.Store) error {
+			if err := tx.Companies().Create(&company); err != nil {
+				return err
+			}
+			if err := tx.Recorder().StageTransition(company, "", ""); err != nil {
+				return err
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityCompany, company.ID, audit.ActionCreate, nil, company); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.CompanyCreated, company)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create company"})
 			return
 		}
@@ -75,14 +134,18 @@ func CreateCompany(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func GetCompany(db *gorm.DB) gin.HandlerFunc {
+func GetCompany(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
 		userID := c.GetString("user_id")
 		companyID := c.Param("id")
 
-		var company models.Company
-		if err := db.Where("id = ? AND user_id = ?", companyID, userID).First(&company).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		company, err := store.Companies().Get(userID, companyID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
 				return
 			}
@@ -94,15 +157,19 @@ func GetCompany(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func UpdateCompany(db *gorm.DB) gin.HandlerFunc {
+func UpdateCompany(store repository.Store, policy StagePolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
 		userID := c.GetString("user_id")
 		companyID := c.Param("id")
 
 		// 既存の企業データを取得
-		var existingCompany models.Company
-		if err := db.Where("id = ? AND user_id = ?", companyID, userID).First(&existingCompany).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		existingCompany, err := store.Companies().Get(userID, companyID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
 				return
 			}
@@ -117,6 +184,8 @@ func UpdateCompany(db *gorm.DB) gin.HandlerFunc {
 			Position     *string `json:"position"`
 			CurrentStage *string `json:"current_stage"`
 			Notes        *string `json:"notes"`
+			StageNote    *string `json:"stage_note"` // ステージ変更時に履歴へ残すメモ
+			Reopen       bool    `json:"reopen"`     // rejected から選考を再開する場合に指定
 		}
 
 		if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -124,6 +193,17 @@ func UpdateCompany(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		before := existingCompany
+		previousStage := existingCompany.CurrentStage
+		stageNote := ""
+		if updateData.StageNote != nil {
+			stageNote = html.EscapeString(strings.TrimSpace(*updateData.StageNote))
+		}
+		if len([]rune(stageNote)) > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: stage_note must be at most 1000 characters"})
+			return
+		}
+
 		// 部分更新の処理
 		if updateData.Name != nil {
 			existingCompany.Name = html.EscapeString(strings.TrimSpace(*updateData.Name))
@@ -148,23 +228,76 @@ func UpdateCompany(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// 自動アーカイブ: rejectedステージの場合は自動的にアーカイブ
-		wasArchived := existingCompany.IsArchived
-		if existingCompany.CurrentStage == "rejected" && !existingCompany.IsArchived {
+		// ステージ遷移ルールのチェック
+		if !policy.Allowed(previousStage, existingCompany.CurrentStage, updateData.Reopen) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":               "Invalid stage transition from " + previousStage + " to " + existingCompany.CurrentStage,
+				"current_stage":       previousStage,
+				"allowed_next_stages": policy.NextStages(previousStage),
+				"requires_reopen":     previousStage == stageRejected,
+			})
+			return
+		}
+
+		settings, err := store.UserSettings(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+
+		// 自動アーカイブ: rejectedステージの場合は自動的にアーカイブ（ユーザー設定で無効化可能）
+		autoArchived := false
+		if settings.AutoArchiveRejectedCompanies && existingCompany.CurrentStage == "rejected" && !existingCompany.IsArchived {
 			existingCompany.IsArchived = true
 			now := time.Now()
 			existingCompany.ArchivedAt = &now
+			existingCompany.AutoArchived = true
+			autoArchived = true
+		}
+		// 選考再開時は不合格による自動アーカイブを解除（手動でのアーカイブはそのまま）
+		if previousStage == stageRejected && existingCompany.CurrentStage != stageRejected && existingCompany.IsArchived && existingCompany.AutoArchived {
+			existingCompany.IsArchived = false
+			existingCompany.ArchivedAt = nil
+			existingCompany.AutoArchived = false
 		}
 
-		// データベースを更新
-		if err := db.Save(&existingCompany).Error; err != nil {
+		// データベースを更新（ステージが変わった場合は履歴も記録）
+		err = store.Transaction(func(tx repository.Store) error {
+			if err := tx.Companies().Save(&existingCompany); err != nil {
+				return err
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityCompany, existingCompany.ID, audit.ActionUpdate, before, existingCompany); err != nil {
+				return err
+			}
+			if err := tx.Recorder().Webhook(userID, webhooks.CompanyUpdated, existingCompany); err != nil {
+				return err
+			}
+			if autoArchived {
+				if err := tx.Recorder().Webhook(userID, webhooks.CompanyArchived, existingCompany); err != nil {
+					return err
+				}
+			}
+			if existingCompany.CurrentStage == previousStage {
+				return nil
+			}
+			if err := tx.Recorder().StageTransition(existingCompany, previousStage, stageNote); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.CompanyStageChanged, gin.H{
+				"company":    existingCompany,
+				"from_stage": previousStage,
+				"to_stage":   existingCompany.CurrentStage,
+				"note":       stageNote,
+			})
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company"})
 			return
 		}
 
 		// レスポンスに自動アーカイブ情報を含める
 		response := existingCompany
-		if existingCompany.CurrentStage == "rejected" && !wasArchived {
+		if autoArchived {
 			// 自動アーカイブされたことを示すフラグを追加
 			c.JSON(http.StatusOK, gin.H{
 				"company":       response,
@@ -177,35 +310,63 @@ func UpdateCompany(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func DeleteCompany(db *gorm.DB) gin.HandlerFunc {
+// DeleteCompany 企業とその予定をゴミ箱に移動（選考履歴は復元に備えて残し、完全に削除する時に消す）
+func DeleteCompany(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
 		userID := c.GetString("user_id")
 		companyID := c.Param("id")
 
-		result := db.Where("id = ? AND user_id = ?", companyID, userID).Delete(&models.Company{})
-		if result.Error != nil {
+		var trashedEvents int
+		err := store.Transaction(func(tx repository.Store) error {
+			company, err := tx.Companies().Delete(userID, companyID)
+			if err != nil {
+				return err
+			}
+			if trashedEvents, err = trashCompanyEvents(tx, auditActor(c), company); err != nil {
+				return err
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityCompany, companyID, audit.ActionDelete, company, nil); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.CompanyDeleted, gin.H{"id": companyID})
+		})
+		if err != nil {
+			if err == repository.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete company"})
 			return
 		}
 
-		if result.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
-			return
-		}
-
-		c.JSON(http.StatusOK, gin.H{"message": "Company deleted successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "Company deleted successfully", "trashed_events": trashedEvents})
 	}
 }
 
 // ArchiveCompany アーカイブ機能
-func ArchiveCompany(db *gorm.DB) gin.HandlerFunc {
+// クエリ: archive_events=true で未アーカイブの予定も一緒にアーカイブする
+func ArchiveCompany(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
 		userID := c.GetString("user_id")
 		companyID := c.Param("id")
 
-		var company models.Company
-		if err := db.Where("id = ? AND user_id = ?", companyID, userID).First(&company).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		archiveEvents, err := parseBoolQuery(c, "archive_events")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		company, err := store.Companies().Get(userID, companyID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
 				return
 			}
@@ -218,31 +379,53 @@ func ArchiveCompany(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		before := company
 		company.IsArchived = true
 		now := time.Now()
 		company.ArchivedAt = &now
 
-		if err := db.Save(&company).Error; err != nil {
+		var archivedEvents int
+		err = store.Transaction(func(tx repository.Store) error {
+			if err := tx.Companies().Save(&company); err != nil {
+				return err
+			}
+			if archiveEvents != nil && *archiveEvents {
+				var err error
+				if archivedEvents, err = archiveCompanyEvents(tx, auditActor(c), company); err != nil {
+					return err
+				}
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityCompany, company.ID, audit.ActionArchive, before, company); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.CompanyArchived, company)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive company"})
 			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"message":     "Company archived successfully",
-			"archived_at": company.ArchivedAt,
+			"message":         "Company archived successfully",
+			"archived_at":     company.ArchivedAt,
+			"archived_events": archivedEvents,
 		})
 	}
 }
 
-// UnarchiveCompany 復元機能
-func UnarchiveCompany(db *gorm.DB) gin.HandlerFunc {
+// UnarchiveCompany 復元機能（企業と一緒にアーカイブした予定も戻す）
+func UnarchiveCompany(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
 		userID := c.GetString("user_id")
 		companyID := c.Param("id")
 
-		var company models.Company
-		if err := db.Where("id = ? AND user_id = ?", companyID, userID).First(&company).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		company, err := store.Companies().Get(userID, companyID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
 				return
 			}
@@ -255,14 +438,29 @@ func UnarchiveCompany(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		before := company
 		company.IsArchived = false
 		company.ArchivedAt = nil
+		company.AutoArchived = false
 
-		if err := db.Save(&company).Error; err != nil {
+		var unarchivedEvents int
+		err = store.Transaction(func(tx repository.Store) error {
+			if err := tx.Companies().Save(&company); err != nil {
+				return err
+			}
+			if before.ArchivedAt != nil {
+				var err error
+				if unarchivedEvents, err = unarchiveCompanyEvents(tx, auditActor(c), company, *before.ArchivedAt); err != nil {
+					return err
+				}
+			}
+			return tx.Recorder().Audit(auditActor(c), audit.EntityCompany, company.ID, audit.ActionUnarchive, before, company)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unarchive company"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Company unarchived successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "Company unarchived successfully", "unarchived_events": unarchivedEvents})
 	}
 }
@@ -0,0 +1,329 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+	"career-schedule-api/internal/webhooks"
+)
+
+func TestCreateAndGetCompany(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+
+	var created models.Company
+	w := do(t, r, userA, http.MethodPost, "/api/v1/companies", map[string]interface{}{
+		"name":          " Acme <Inc> ",
+		"current_stage": "entry",
+		"user_id":       userB,
+	})
+	expectStatus(t, w, http.StatusCreated, &created)
+	if created.ID == "" || created.UserID != userA {
+		t.Fatalf("created = %+v, want an ID owned by userA", created)
+	}
+	if created.Name != "Acme &lt;Inc&gt;" {
+		t.Errorf("name = %q, want sanitized", created.Name)
+	}
+
+	transitions := store.StageTransitions()
+	if len(transitions) != 1 || transitions[0].FromStage != "" || transitions[0].ToStage != "entry" {
+		t.Errorf("stage transitions = %+v, want the initial stage", transitions)
+	}
+	audits := store.Audits()
+	if len(audits) != 1 || audits[0].Action != audit.ActionCreate || audits[0].EntityID != created.ID {
+		t.Errorf("audits = %+v, want a create entry", audits)
+	}
+	if kinds := webhookKinds(store); len(kinds) != 1 || kinds[0] != webhooks.CompanyCreated {
+		t.Errorf("webhooks = %v, want [%s]", kinds, webhooks.CompanyCreated)
+	}
+
+	var fetched models.Company
+	w = do(t, r, userA, http.MethodGet, "/api/v1/companies/"+created.ID, nil)
+	expectStatus(t, w, http.StatusOK, &fetched)
+	if fetched.Name != created.Name {
+		t.Errorf("fetched name = %q, want %q", fetched.Name, created.Name)
+	}
+
+	// 他のユーザーの企業は存在しないものとして扱う
+	w = do(t, r, userB, http.MethodGet, "/api/v1/companies/"+created.ID, nil)
+	expectStatus(t, w, http.StatusNotFound, nil)
+}
+
+func TestCreateCompanyValidation(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"missing name", map[string]interface{}{"current_stage": "entry"}},
+		{"unknown stage", map[string]interface{}{"name": "Acme", "current_stage": "hired"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(t, r, userA, http.MethodPost, "/api/v1/companies", tt.body)
+			expectStatus(t, w, http.StatusBadRequest, nil)
+		})
+	}
+	if len(store.Audits()) != 0 {
+		t.Errorf("audits = %+v, want none", store.Audits())
+	}
+}
+
+func TestGetCompaniesFilterAndPagination(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+
+	for _, name := range []string{"Charlie", "Alpha", "Bravo"} {
+		seedCompany(store, userA, name, "entry")
+	}
+	archived := seedCompany(store, userA, "Delta", "offer")
+	archived.IsArchived = true
+	store.PutCompany(archived)
+	seedCompany(store, userB, "Other", "entry")
+
+	var active []models.Company
+	w := do(t, r, userA, http.MethodGet, "/api/v1/companies?is_archived=false&sort=name&order=asc", nil)
+	expectStatus(t, w, http.StatusOK, &active)
+	if len(active) != 3 || active[0].Name != "Alpha" || active[2].Name != "Charlie" {
+		t.Fatalf("companies = %+v, want Alpha, Bravo, Charlie", active)
+	}
+
+	var page struct {
+		Items      []models.Company `json:"items"`
+		NextCursor *string          `json:"next_cursor"`
+		Total      int64            `json:"total"`
+	}
+	w = do(t, r, userA, http.MethodGet, "/api/v1/companies?sort=name&order=asc&limit=2", nil)
+	expectStatus(t, w, http.StatusOK, &page)
+	if page.Total != 4 || len(page.Items) != 2 || page.NextCursor == nil || page.Items[1].Name != "Bravo" {
+		t.Fatalf("first page = %+v, want 2 of 4 items with a cursor", page)
+	}
+
+	cursor := *page.NextCursor
+	page.Items, page.NextCursor = nil, nil
+	w = do(t, r, userA, http.MethodGet, "/api/v1/companies?sort=name&order=asc&limit=2&cursor="+cursor, nil)
+	expectStatus(t, w, http.StatusOK, &page)
+	if len(page.Items) != 2 || page.NextCursor != nil || page.Items[0].Name != "Charlie" || page.Items[1].Name != "Delta" {
+		t.Fatalf("second page = %+v, want Charlie, Delta without a cursor", page)
+	}
+
+	w = do(t, r, userA, http.MethodGet, "/api/v1/companies?current_stage=offer", nil)
+	expectStatus(t, w, http.StatusOK, &active)
+	if len(active) != 1 || active[0].ID != archived.ID {
+		t.Errorf("companies = %+v, want only Delta", active)
+	}
+
+	w = do(t, r, userA, http.MethodGet, "/api/v1/companies?sort=unknown", nil)
+	expectStatus(t, w, http.StatusBadRequest, nil)
+}
+
+func TestUpdateCompanyStageTransition(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+
+	var updated models.Company
+	w := do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{
+		"current_stage": "document_review",
+		"stage_note":    "ES提出",
+	})
+	expectStatus(t, w, http.StatusOK, &updated)
+	if updated.CurrentStage != "document_review" {
+		t.Errorf("stage = %q, want document_review", updated.CurrentStage)
+	}
+	transitions := store.StageTransitions()
+	if len(transitions) != 1 || transitions[0].FromStage != "entry" || transitions[0].Note != "ES提出" {
+		t.Errorf("stage transitions = %+v, want entry -> document_review", transitions)
+	}
+	if !contains(webhookKinds(store), webhooks.CompanyStageChanged) {
+		t.Errorf("webhooks = %v, want %s", webhookKinds(store), webhooks.CompanyStageChanged)
+	}
+
+	// 次のステージ以外へは進めない
+	var conflict struct {
+		CurrentStage      string   `json:"current_stage"`
+		AllowedNextStages []string `json:"allowed_next_stages"`
+	}
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{"current_stage": "offer"})
+	expectStatus(t, w, http.StatusConflict, &conflict)
+	if conflict.CurrentStage != "document_review" || !contains(conflict.AllowedNextStages, "first_interview") {
+		t.Errorf("conflict = %+v", conflict)
+	}
+	if stored, _ := store.Company(company.ID); stored.CurrentStage != "document_review" {
+		t.Errorf("stored stage = %q, want unchanged", stored.CurrentStage)
+	}
+
+	// ステージを変えない更新では履歴を追加しない
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{"notes": "memo"})
+	expectStatus(t, w, http.StatusOK, nil)
+	if n := len(store.StageTransitions()); n != 1 {
+		t.Errorf("stage transitions = %d, want 1", n)
+	}
+
+	w = do(t, r, userB, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{"notes": "memo"})
+	expectStatus(t, w, http.StatusNotFound, nil)
+}
+
+func TestUpdateCompanyRejectedAutoArchive(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "first_interview")
+
+	var response struct {
+		Company      models.Company `json:"company"`
+		AutoArchived bool           `json:"auto_archived"`
+	}
+	w := do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{"current_stage": "rejected"})
+	expectStatus(t, w, http.StatusOK, &response)
+	if !response.AutoArchived || !response.Company.IsArchived || response.Company.ArchivedAt == nil {
+		t.Fatalf("response = %+v, want auto-archived", response)
+	}
+	if !contains(webhookKinds(store), webhooks.CompanyArchived) {
+		t.Errorf("webhooks = %v, want %s", webhookKinds(store), webhooks.CompanyArchived)
+	}
+
+	// rejected から再開するには reopen が必要で、再開すると自動アーカイブを解除する
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{"current_stage": "entry"})
+	expectStatus(t, w, http.StatusConflict, nil)
+
+	var reopened models.Company
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{"current_stage": "entry", "reopen": true})
+	expectStatus(t, w, http.StatusOK, &reopened)
+	if reopened.IsArchived || reopened.ArchivedAt != nil {
+		t.Errorf("reopened = %+v, want unarchived", reopened)
+	}
+}
+
+func TestUpdateCompanyRejectedAutoArchiveDisabled(t *testing.T) {
+	store := repository.NewMemoryStore()
+	settings := models.NewUserSettings(userA)
+	settings.AutoArchiveRejectedCompanies = false
+	store.SetUserSettings(settings)
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+
+	var updated models.Company
+	w := do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{"current_stage": "rejected"})
+	expectStatus(t, w, http.StatusOK, &updated)
+	if updated.CurrentStage != "rejected" || updated.IsArchived {
+		t.Errorf("updated = %+v, want rejected without archiving", updated)
+	}
+
+	// 手動でのアーカイブは選考を再開しても解除しない
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID+"/archive", nil)
+	expectStatus(t, w, http.StatusOK, nil)
+	var reopened models.Company
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID, map[string]interface{}{"current_stage": "entry", "reopen": true})
+	expectStatus(t, w, http.StatusOK, &reopened)
+	if reopened.CurrentStage != "entry" || !reopened.IsArchived || reopened.ArchivedAt == nil {
+		t.Errorf("reopened = %+v, want the manual archive kept", reopened)
+	}
+}
+
+func TestDeleteCompanyTrashesEvents(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+	first := seedEvent(store, company, "一次面接")
+	second := seedEvent(store, company, "二次面接")
+	other := seedEvent(store, seedCompany(store, userA, "Other", "entry"), "説明会")
+
+	w := do(t, r, userB, http.MethodDelete, "/api/v1/companies/"+company.ID, nil)
+	expectStatus(t, w, http.StatusNotFound, nil)
+
+	var response struct {
+		TrashedEvents int `json:"trashed_events"`
+	}
+	w = do(t, r, userA, http.MethodDelete, "/api/v1/companies/"+company.ID, nil)
+	expectStatus(t, w, http.StatusOK, &response)
+	if response.TrashedEvents != 2 {
+		t.Errorf("trashed_events = %d, want 2", response.TrashedEvents)
+	}
+
+	trashed, _ := store.Company(company.ID)
+	if !trashed.DeletedAt.Valid {
+		t.Fatalf("company was not moved to the trash")
+	}
+	for _, id := range []string{first.ID, second.ID} {
+		event, _ := store.Event(id)
+		if !event.DeletedAt.Valid || !event.DeletedAt.Time.Equal(trashed.DeletedAt.Time) {
+			t.Errorf("event %s deleted_at = %+v, want the company's %v", id, event.DeletedAt, trashed.DeletedAt.Time)
+		}
+		w = do(t, r, userA, http.MethodGet, "/api/v1/events/"+id, nil)
+		expectStatus(t, w, http.StatusNotFound, nil)
+	}
+	if event, _ := store.Event(other.ID); event.DeletedAt.Valid {
+		t.Errorf("event of another company was trashed")
+	}
+
+	w = do(t, r, userA, http.MethodGet, "/api/v1/companies/"+company.ID, nil)
+	expectStatus(t, w, http.StatusNotFound, nil)
+	w = do(t, r, userA, http.MethodDelete, "/api/v1/companies/"+company.ID, nil)
+	expectStatus(t, w, http.StatusNotFound, nil)
+}
+
+func TestArchiveAndUnarchiveCompany(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+	cascaded := seedEvent(store, company, "一次面接")
+	archivedBefore := seedEvent(store, company, "説明会")
+
+	// 企業より前に個別にアーカイブした予定
+	w := do(t, r, userA, http.MethodPut, "/api/v1/events/"+archivedBefore.ID+"/archive", nil)
+	expectStatus(t, w, http.StatusOK, nil)
+
+	var archived struct {
+		ArchivedEvents int `json:"archived_events"`
+	}
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID+"/archive?archive_events=true", nil)
+	expectStatus(t, w, http.StatusOK, &archived)
+	if archived.ArchivedEvents != 1 {
+		t.Errorf("archived_events = %d, want 1", archived.ArchivedEvents)
+	}
+	storedCompany, _ := store.Company(company.ID)
+	if event, _ := store.Event(cascaded.ID); !event.IsArchived || event.ArchivedAt == nil || !event.ArchivedAt.Equal(*storedCompany.ArchivedAt) {
+		t.Errorf("cascaded event = %+v, want archived with the company's archived_at", event)
+	}
+
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID+"/archive", nil)
+	expectStatus(t, w, http.StatusBadRequest, nil)
+
+	var unarchived struct {
+		UnarchivedEvents int `json:"unarchived_events"`
+	}
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID+"/unarchive", nil)
+	expectStatus(t, w, http.StatusOK, &unarchived)
+	if unarchived.UnarchivedEvents != 1 {
+		t.Errorf("unarchived_events = %d, want 1", unarchived.UnarchivedEvents)
+	}
+	if event, _ := store.Event(cascaded.ID); event.IsArchived {
+		t.Errorf("cascaded event is still archived")
+	}
+	if event, _ := store.Event(archivedBefore.ID); !event.IsArchived {
+		t.Errorf("event archived before the company was unarchived")
+	}
+
+	w = do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID+"/unarchive", nil)
+	expectStatus(t, w, http.StatusBadRequest, nil)
+	w = do(t, r, userB, http.MethodPut, "/api/v1/companies/"+company.ID+"/archive", nil)
+	expectStatus(t, w, http.StatusNotFound, nil)
+}
+
+func TestArchiveCompanyWithoutEvents(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+	event := seedEvent(store, company, "一次面接")
+
+	w := do(t, r, userA, http.MethodPut, "/api/v1/companies/"+company.ID+"/archive", nil)
+	expectStatus(t, w, http.StatusOK, nil)
+	if stored, _ := store.Event(event.ID); stored.IsArchived {
+		t.Errorf("event was archived without archive_events=true")
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeSlot candidate_slots / confirmed_slot の1枠
+type timeSlot struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// valid 開始・終了が設定され、開始が終了より前であること
+func (s timeSlot) valid() bool {
+	return !s.StartTime.IsZero() && !s.EndTime.IsZero() && s.StartTime.Before(s.EndTime)
+}
+
+// scheduleConflict 既存の確定予定との競合情報
+type scheduleConflict struct {
+	EventID        string   `json:"event_id"`
+	CompanyID      string   `json:"company_id"`
+	CompanyName    string   `json:"company_name"`
+	Title          string   `json:"title"`
+	Type           string   `json:"type"`
+	ConfirmedSlot  timeSlot `json:"confirmed_slot"`
+	OverlapMinutes int      `json:"overlap_minutes"`
+}
+
+// listConfirmedEvents 競合判定の対象となる、アーカイブされていない確定済み予定
+func listConfirmedEvents(store repository.Store, userID string) ([]models.Event, error) {
+	notArchived := false
+	return store.Events().List(userID, repository.EventFilter{IsArchived: &notArchived, Status: "confirmed"}, repository.Page{})
+}
+
+// detectScheduleConflicts 確定済み予定 events のうち、提案された枠と競合するものを返す
+// 既存予定の前後に bufferMinutes を加えた範囲と重なる場合を競合とみなし、
+// 重複時間（分）はバッファ込みの範囲で計算する
+func detectScheduleConflicts(events []models.Event, slot timeSlot, bufferMinutes int, excludeEventID string) []scheduleConflict {
+	buffer := time.Duration(bufferMinutes) * time.Minute
+	conflicts := []scheduleConflict{}
+	for _, event := range events {
+		if event.ID == excludeEventID || len(event.ConfirmedSlot) == 0 {
+			continue
+		}
+		var confirmed timeSlot
+		if err := json.Unmarshal(event.ConfirmedSlot, &confirmed); err != nil || !confirmed.valid() {
+			// 壊れたデータは競合判定の対象外
+			continue
+		}
+
+		bufferedStart := confirmed.StartTime.Add(-buffer)
+		bufferedEnd := confirmed.EndTime.Add(buffer)
+		if !slot.StartTime.Before(bufferedEnd) || !slot.EndTime.After(bufferedStart) {
+			continue
+		}
+
+		overlapStart := slot.StartTime
+		if bufferedStart.After(overlapStart) {
+			overlapStart = bufferedStart
+		}
+		overlapEnd := slot.EndTime
+		if bufferedEnd.Before(overlapEnd) {
+			overlapEnd = bufferedEnd
+		}
+
+		conflicts = append(conflicts, scheduleConflict{
+			EventID:        event.ID,
+			CompanyID:      event.CompanyID,
+			CompanyName:    event.CompanyName,
+			Title:          event.Title,
+			Type:           event.Type,
+			ConfirmedSlot:  confirmed,
+			OverlapMinutes: int(overlapEnd.Sub(overlapStart).Minutes()),
+		})
+	}
+
+	return conflicts
+}
+
+// outsideWorkingHours 枠がユーザー設定の勤務時間（ユーザーのタイムゾーン）からはみ出しているか
+func outsideWorkingHours(slot timeSlot, settings models.UserSettings) bool {
+	start, err := parseClock(settings.WorkingHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(settings.WorkingHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	loc := settings.Location()
+	localStart := slot.StartTime.In(loc)
+	localEnd := slot.EndTime.In(loc)
+	if localStart.YearDay() != localEnd.YearDay() || localStart.Year() != localEnd.Year() {
+		return true
+	}
+	return localStart.Hour()*60+localStart.Minute() < start || localEnd.Hour()*60+localEnd.Minute() > end
+}
+
+// CheckEventConflicts 提案された枠が確定済みの予定と競合するかを判定
+func CheckEventConflicts(store repository.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var request struct {
+			StartTime      time.Time `json:"start_time"`
+			EndTime        time.Time `json:"end_time"`
+			ExcludeEventID string    `json:"exclude_event_id"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		slot := timeSlot{StartTime: request.StartTime, EndTime: request.EndTime}
+		if !slot.valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time range"})
+			return
+		}
+
+		settings, err := store.UserSettings(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+
+		confirmedEvents, err := listConfirmedEvents(store, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check conflicts"})
+			return
+		}
+		conflicts := detectScheduleConflicts(confirmedEvents, slot, settings.ConflictBufferMinutes, request.ExcludeEventID)
+
+		c.JSON(http.StatusOK, gin.H{
+			"has_conflict":          len(conflicts) > 0,
+			"buffer_minutes":        settings.ConflictBufferMinutes,
+			"conflicts":             conflicts,
+			"outside_working_hours": outsideWorkingHours(slot, settings),
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+
+	"gorm.io/datatypes"
+)
+
+// seedConfirmedEvent company の確定済み予定（start〜end）
+func seedConfirmedEvent(store *repository.MemoryStore, company models.Company, start, end time.Time) models.Event {
+	event := seedEvent(store, company, "最終面接")
+	event.Status = "confirmed"
+	event.ConfirmedSlot = datatypes.JSON(slotJSON(start, end))
+	return store.PutEvent(event)
+}
+
+func TestCheckEventConflicts(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, jst)
+	company := seedCompany(store, userA, "Acme", "entry")
+
+	// 13:00〜14:00 に確定済み（バッファ30分で 12:30〜14:30 と競合する）
+	existing := seedConfirmedEvent(store, company, day.Add(13*time.Hour), day.Add(14*time.Hour))
+	archived := seedConfirmedEvent(store, company, day.Add(13*time.Hour), day.Add(14*time.Hour))
+	archived.IsArchived = true
+	store.PutEvent(archived)
+	seedConfirmedEvent(store, seedCompany(store, userB, "Other", "entry"), day.Add(13*time.Hour), day.Add(14*time.Hour))
+
+	type response struct {
+		HasConflict         bool `json:"has_conflict"`
+		OutsideWorkingHours bool `json:"outside_working_hours"`
+		Conflicts           []struct {
+			EventID        string `json:"event_id"`
+			OverlapMinutes int    `json:"overlap_minutes"`
+		} `json:"conflicts"`
+	}
+	request := func(start, end time.Time, exclude string) map[string]interface{} {
+		return map[string]interface{}{"start_time": start, "end_time": end, "exclude_event_id": exclude}
+	}
+
+	var got response
+	w := do(t, r, userA, http.MethodPost, "/api/v1/events/conflicts", request(day.Add(12*time.Hour), day.Add(13*time.Hour), ""))
+	expectStatus(t, w, http.StatusOK, &got)
+	if !got.HasConflict || len(got.Conflicts) != 1 || got.Conflicts[0].EventID != existing.ID || got.Conflicts[0].OverlapMinutes != 30 {
+		t.Errorf("response = %+v, want a 30 minute overlap with %s only", got, existing.ID)
+	}
+
+	// 変更中の予定自体は除外できる
+	got = response{}
+	w = do(t, r, userA, http.MethodPost, "/api/v1/events/conflicts", request(day.Add(12*time.Hour), day.Add(13*time.Hour), existing.ID))
+	expectStatus(t, w, http.StatusOK, &got)
+	if got.HasConflict || len(got.Conflicts) != 0 {
+		t.Errorf("response = %+v, want no conflict when excluding %s", got, existing.ID)
+	}
+
+	// 勤務時間（既定 9:00〜18:00）外
+	got = response{}
+	w = do(t, r, userA, http.MethodPost, "/api/v1/events/conflicts", request(day.Add(19*time.Hour), day.Add(20*time.Hour), ""))
+	expectStatus(t, w, http.StatusOK, &got)
+	if got.HasConflict || !got.OutsideWorkingHours {
+		t.Errorf("response = %+v, want outside working hours without conflict", got)
+	}
+
+	w = do(t, r, userA, http.MethodPost, "/api/v1/events/conflicts", request(day.Add(13*time.Hour), day.Add(12*time.Hour), ""))
+	expectStatus(t, w, http.StatusBadRequest, nil)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"career-schedule-api/internal/digest"
+	"career-schedule-api/internal/notify"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SendDigest 日次ダイジェストを今すぐ送る（ダイジェストの有効・無効に関わらず送信できる）
+// クエリ: dry_run=true の場合は送信せず、件名・本文と送信されるメール全体を返す
+// 宛先は設定の digest_email、未設定ならログイン中のメールアドレス
+func SendDigest(db *gorm.DB, mailer notify.SMTPNotifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		dryRun, err := parseBoolQuery(c, "dry_run")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		settings, err := loadUserSettings(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+		to := settings.DigestEmail
+		if to == "" {
+			to = c.GetString("user_email")
+		}
+
+		now := time.Now()
+		d, err := digest.Build(db, settings, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build digest"})
+			return
+		}
+		subject, body := d.Subject(), d.Body()
+
+		if dryRun != nil && *dryRun {
+			c.JSON(http.StatusOK, gin.H{
+				"dry_run": true,
+				"to":      to,
+				"subject": subject,
+				"body":    body,
+				"message": string(mailer.BuildMessage(to, subject, body, now)),
+				"digest":  d,
+			})
+			return
+		}
+
+		if mailer.Host == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "SMTP is not configured"})
+			return
+		}
+		if to == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "digest_email is not set"})
+			return
+		}
+		if err := mailer.Notify(c.Request.Context(), notify.Message{To: to, Subject: subject, Body: body}); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send digest: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"dry_run": false,
+			"to":      to,
+			"subject": subject,
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"sort"
+	"time"
+
+	"career-schedule-api/internal/emailtemplate"
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// emailTemplateValues 予定からテンプレートのプレースホルダー値を組み立てる
+func emailTemplateValues(event models.Event, loc *time.Location) (map[string]string, error) {
+	var candidates []timeSlot
+	if len(event.CandidateSlots) > 0 {
+		if err := json.Unmarshal(event.CandidateSlots, &candidates); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].StartTime.Before(candidates[j].StartTime)
+	})
+	slots := make([]emailtemplate.Slot, 0, len(candidates))
+	for _, cs := range candidates {
+		if cs.valid() {
+			slots = append(slots, emailtemplate.Slot{Start: cs.StartTime, End: cs.EndTime})
+		}
+	}
+
+	confirmedText := "未確定"
+	if len(event.ConfirmedSlot) > 0 {
+		var confirmed timeSlot
+		if err := json.Unmarshal(event.ConfirmedSlot, &confirmed); err == nil && confirmed.valid() {
+			confirmedText = emailtemplate.FormatSlot(emailtemplate.Slot{Start: confirmed.StartTime, End: confirmed.EndTime}, loc)
+		}
+	}
+
+	location := html.UnescapeString(event.Location)
+	if location == "" && event.IsOnline {
+		location = "オンライン"
+	}
+
+	return map[string]string{
+		"company_name":    html.UnescapeString(event.CompanyName),
+		"title":           html.UnescapeString(event.Title),
+		"candidate_slots": emailtemplate.FormatSlots(slots, loc),
+		"confirmed_slot":  confirmedText,
+		"duration":        emailtemplate.FormatDuration(event.InterviewDuration),
+		"location":        location,
+	}, nil
+}
+
+// resolveEmailTemplate 予定に使うテンプレートを決定する
+// 優先順位: 紐付いたテンプレート → カスタムフォーマット → 種別デフォルト → ユーザーデフォルト → 組み込み
+func resolveEmailTemplate(db *gorm.DB, event models.Event) (string, string, error) {
+	if event.EmailTemplateID != nil {
+		var template models.EmailTemplate
+		err := db.Where("id = ? AND user_id = ?", *event.EmailTemplateID, event.UserID).First(&template).Error
+		if err == nil {
+			return template.Body, "event_template", nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return "", "", err
+		}
+	}
+
+	if event.CustomEmailFormat != "" {
+		return event.CustomEmailFormat, "event", nil
+	}
+
+	var defaults []models.EmailTemplate
+	if err := db.Where("user_id = ? AND is_default = ? AND event_type IN ?", event.UserID, true, []string{event.Type, ""}).
+		Find(&defaults).Error; err != nil {
+		return "", "", err
+	}
+	for _, t := range defaults {
+		if t.EventType == event.Type {
+			return t.Body, "type_default", nil
+		}
+	}
+	for _, t := range defaults {
+		if t.EventType == "" {
+			return t.Body, "user_default", nil
+		}
+	}
+
+	return emailtemplate.DefaultTemplate, "default", nil
+}
+
+// RenderEventEmail 予定のメール本文をテンプレートから生成
+func RenderEventEmail(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		eventID := c.Param("id")
+
+		var event models.Event
+		if err := db.Where("id = ? AND user_id = ?", eventID, userID).First(&event).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event"})
+			return
+		}
+
+		template, source, err := resolveEmailTemplate(db, event)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email template"})
+			return
+		}
+
+		settings, err := loadUserSettings(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+
+		values, err := emailTemplateValues(event, settings.Location())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse candidate slots"})
+			return
+		}
+
+		body, err := emailtemplate.Render(template, values)
+		if err != nil {
+			// 保存時に検証しているが、検証導入前のデータは壊れている可能性がある
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid email template: " + err.Error(), "source": source})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"body":     body,
+			"template": template,
+			"source":   source,
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/emailtemplate"
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// validateEmailTemplate 構造体のバリデーションとテンプレート構文の検証
+func validateEmailTemplate(template *models.EmailTemplate) error {
+	validate := validator.New()
+	if err := validate.Struct(template); err != nil {
+		return err
+	}
+	return emailtemplate.Validate(template.Body)
+}
+
+// saveEmailTemplate デフォルト指定時は同じ対象（種別）の他テンプレートのデフォルトを解除して保存
+// before は変更前のテンプレート（新規作成時は nil）で、監査ログに記録する
+func saveEmailTemplate(db *gorm.DB, actor audit.Actor, before, template *models.EmailTemplate) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if template.IsDefault {
+			query := tx.Model(&models.EmailTemplate{}).
+				Where("user_id = ? AND event_type = ? AND is_default = ?", template.UserID, template.EventType, true)
+			if template.ID != "" {
+				query = query.Where("id <> ?", template.ID)
+			}
+			if err := query.Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(template).Error; err != nil {
+			return err
+		}
+		if before == nil {
+			return audit.Record(tx, actor, audit.EntityEmailTemplate, template.ID, audit.ActionCreate, nil, template)
+		}
+		return audit.Record(tx, actor, audit.EntityEmailTemplate, template.ID, audit.ActionUpdate, before, template)
+	})
+}
+
+func GetEmailTemplates(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var templates []models.EmailTemplate
+		if err := db.Where("user_id = ?", userID).
+			Order("event_type ASC, name ASC").
+			Find(&templates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email templates"})
+			return
+		}
+
+		c.JSON(http.StatusOK, templates)
+	}
+}
+
+func CreateEmailTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var request struct {
+			Name      string `json:"name"`
+			Body      string `json:"body"`
+			EventType string `json:"event_type"`
+			IsDefault bool   `json:"is_default"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		template := models.EmailTemplate{
+			UserID:    userID,
+			Name:      strings.TrimSpace(request.Name),
+			Body:      request.Body,
+			EventType: request.EventType,
+			IsDefault: request.IsDefault,
+		}
+
+		if err := validateEmailTemplate(&template); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+			return
+		}
+
+		if err := saveEmailTemplate(db, auditActor(c), nil, &template); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create email template"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, template)
+	}
+}
+
+func GetEmailTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		templateID := c.Param("id")
+
+		var template models.EmailTemplate
+		if err := db.Where("id = ? AND user_id = ?", templateID, userID).First(&template).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email template"})
+			return
+		}
+
+		c.JSON(http.StatusOK, template)
+	}
+}
+
+func UpdateEmailTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		templateID := c.Param("id")
+
+		var template models.EmailTemplate
+		if err := db.Where("id = ? AND user_id = ?", templateID, userID).First(&template).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email template"})
+			return
+		}
+		before := template
+
+		// 更新用のデータ構造
+		var updateData struct {
+			Name      *string `json:"name"`
+			Body      *string `json:"body"`
+			EventType *string `json:"event_type"`
+			IsDefault *bool   `json:"is_default"`
+		}
+		if err := c.ShouldBindJSON(&updateData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 部分更新の処理
+		if updateData.Name != nil {
+			template.Name = strings.TrimSpace(*updateData.Name)
+		}
+		if updateData.Body != nil {
+			template.Body = *updateData.Body
+		}
+		if updateData.EventType != nil {
+			template.EventType = *updateData.EventType
+		}
+		if updateData.IsDefault != nil {
+			template.IsDefault = *updateData.IsDefault
+		}
+
+		if err := validateEmailTemplate(&template); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+			return
+		}
+
+		if err := saveEmailTemplate(db, auditActor(c), &before, &template); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email template"})
+			return
+		}
+
+		c.JSON(http.StatusOK, template)
+	}
+}
+
+// DeleteEmailTemplate テンプレートを削除し、参照している予定の紐付けを解除
+func DeleteEmailTemplate(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		templateID := c.Param("id")
+
+		var rowsAffected int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var template models.EmailTemplate
+			result := tx.Clauses(clause.Returning{}).Where("id = ? AND user_id = ?", templateID, userID).Delete(&template)
+			if result.Error != nil {
+				return result.Error
+			}
+			rowsAffected = result.RowsAffected
+			if rowsAffected == 0 {
+				return nil
+			}
+			if err := tx.Model(&models.Event{}).
+				Where("user_id = ? AND email_template_id = ?", userID, templateID).
+				Update("email_template_id", nil).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, auditActor(c), audit.EntityEmailTemplate, templateID, audit.ActionDelete, template, nil)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete email template"})
+			return
+		}
+
+		if rowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Email template deleted successfully"})
+	}
+}
@@ -7,7 +7,12 @@ import (
 	"strings"
 	"time"
 
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/emailtemplate"
+	"career-schedule-api/internal/jobs"
 	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+	"career-schedule-api/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -15,31 +20,86 @@ import (
 	"gorm.io/gorm"
 )
 
-func GetEvents(db *gorm.DB) gin.HandlerFunc {
+// GetEvents 予定一覧
+// クエリ: status, type, company_id, is_archived, from / to（確定日時の開始）で絞り込み、sort / order で並び替え
+// limit / cursor を指定した場合はページネーション形式で返す
+func GetEvents(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 
-		var events []models.Event
-		// クエリ最適化: 必要なフィールドのみ選択、インデックス活用
-		if err := db.Select("id, company_id, user_id, company_name, title, type, status, candidate_slots, confirmed_slot, interview_duration, custom_email_format, location, is_online, notes, is_archived, archived_at, created_at, updated_at").
-			Where("user_id = ?", userID).
-			Order("created_at DESC"). // 最新作成順でソート
-			Find(&events).Error; err != nil {
+		params, err := parseListParams(c, repository.EventSortKeys, "created_at")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		isArchived, err := parseBoolQuery(c, "is_archived")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		settings, err := store.UserSettings(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+		dates, err := parseDateRange(c, settings.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format, expected YYYY-MM-DD"})
+			return
+		}
+
+		filter := repository.EventFilter{
+			IsArchived: isArchived,
+			Status:     c.Query("status"),
+			Type:       c.Query("type"),
+			CompanyID:  c.Query("company_id"),
+			From:       dates.From,
+			To:         dates.To,
+		}
+
+		var total int64
+		page := params.Page
+		page.Limit = 0
+		if params.Paginated {
+			if total, err = store.Events().Count(userID, filter); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+				return
+			}
+			page.Limit = params.Limit + 1
+		} else {
+			page.Cursor = nil
+		}
+
+		events, err := store.Events().List(userID, filter, page)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 			return
 		}
 
-		c.JSON(http.StatusOK, events)
+		if !params.Paginated {
+			c.JSON(http.StatusOK, events)
+			return
+		}
+
+		response := pageResponse{Items: events, Total: total}
+		if len(events) > params.Limit {
+			events = events[:params.Limit]
+			last := events[len(events)-1]
+			next := encodeCursor(cursorValue(repository.EventSortValue(last, params.SortName)), last.ID)
+			response.Items = events
+			response.NextCursor = &next
+		}
+		c.JSON(http.StatusOK, response)
 	}
 }
 
-func CreateEvent(db *gorm.DB) gin.HandlerFunc {
+func CreateEvent(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
@@ -58,6 +118,17 @@ func CreateEvent(db *gorm.DB) gin.HandlerFunc {
 		event.Notes = html.EscapeString(strings.TrimSpace(event.Notes))
 
 		event.UserID = userID
+		event.DeletedAt = gorm.DeletedAt{}
+
+		// 所要時間が未指定の場合はユーザー設定のデフォルト値を使う
+		if event.InterviewDuration == 0 {
+			settings, err := store.UserSettings(userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+				return
+			}
+			event.InterviewDuration = settings.DefaultInterviewDuration
+		}
 
 		// バリデーション
 		validate := validator.New()
@@ -65,8 +136,39 @@ func CreateEvent(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
 			return
 		}
+		if err := emailtemplate.Validate(event.CustomEmailFormat); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email template: " + err.Error()})
+			return
+		}
+		if _, err := store.Companies().Get(userID, event.CompanyID); err != nil {
+			if err == repository.ErrNotFound {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+			return
+		}
+		if event.EmailTemplateID != nil {
+			if err := store.CheckEmailTemplate(userID, *event.EmailTemplateID); err != nil {
+				if err == repository.ErrNotFound {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Email template not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email template"})
+				return
+			}
+		}
 
-		if err := db.Create(&event).Error; err != nil {
+		err := store.Transaction(func(tx repository.Store) error {
+			if err := tx.Events().Create(&event); err != nil {
+				return err
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityEvent, event.ID, audit.ActionCreate, nil, event); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.EventCreated, event)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
 			return
 		}
@@ -75,18 +177,18 @@ func CreateEvent(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func GetEvent(db *gorm.DB) gin.HandlerFunc {
+func GetEvent(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 		eventID := c.Param("id")
 
-		var event models.Event
-		if err := db.Where("id = ? AND user_id = ?", eventID, userID).First(&event).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		event, err := store.Events().Get(userID, eventID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 				return
 			}
@@ -98,29 +200,37 @@ func GetEvent(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func UpdateEvent(db *gorm.DB) gin.HandlerFunc {
+// UpdateEvent 予定の更新（確定日時・ステータスの変更に合わせてリマインダーも再計算する）
+func UpdateEvent(store repository.Store, reminders jobs.ReminderDefaults) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 		eventID := c.Param("id")
 
-		var event models.Event
-		if err := db.Where("id = ? AND user_id = ?", eventID, userID).First(&event).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		event, err := store.Events().Get(userID, eventID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 				return
 			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event"})
 			return
 		}
+		before := event
 
 		if err := c.ShouldBindJSON(&event); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		// ID・所有者は変更させず、ゴミ箱への移動・復元は専用のAPIでのみ行う
+		event.ID = before.ID
+		event.UserID = before.UserID
+		event.DeletedAt = before.DeletedAt
+		// SEQUENCE はサーバーで加算する（保存時の BeforeUpdate）
+		event.Sequence = before.Sequence
 
 		// 入力値サニタイゼーション
 		event.CompanyName = html.EscapeString(strings.TrimSpace(event.CompanyName))
@@ -134,8 +244,44 @@ func UpdateEvent(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
 			return
 		}
+		if err := emailtemplate.Validate(event.CustomEmailFormat); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email template: " + err.Error()})
+			return
+		}
+		if event.CompanyID != before.CompanyID {
+			if _, err := store.Companies().Get(userID, event.CompanyID); err != nil {
+				if err == repository.ErrNotFound {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Company not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+				return
+			}
+		}
+		if event.EmailTemplateID != nil {
+			if err := store.CheckEmailTemplate(userID, *event.EmailTemplateID); err != nil {
+				if err == repository.ErrNotFound {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Email template not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email template"})
+				return
+			}
+		}
 
-		if err := db.Save(&event).Error; err != nil {
+		err = store.Transaction(func(tx repository.Store) error {
+			if err := tx.Events().Save(&event); err != nil {
+				return err
+			}
+			if err := tx.Recorder().SyncReminders(event, reminders, c.GetString("user_email")); err != nil {
+				return err
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityEvent, event.ID, audit.ActionUpdate, before, event); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.EventUpdated, event)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
 			return
 		}
@@ -144,43 +290,52 @@ func UpdateEvent(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-func DeleteEvent(db *gorm.DB) gin.HandlerFunc {
+// DeleteEvent 予定をゴミ箱に移動（リマインダーは送信対象から外れ、復元に備えて残す）
+func DeleteEvent(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 		eventID := c.Param("id")
 
-		result := db.Where("id = ? AND user_id = ?", eventID, userID).Delete(&models.Event{})
-		if result.Error != nil {
+		err := store.Transaction(func(tx repository.Store) error {
+			event, err := tx.Events().Delete(userID, eventID)
+			if err != nil {
+				return err
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityEvent, eventID, audit.ActionDelete, event, nil); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.EventDeleted, gin.H{"id": eventID})
+		})
+		if err != nil {
+			if err == repository.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
 			return
 		}
 
-		if result.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
-			return
-		}
-
 		c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
 	}
 }
 
 // ArchiveEvent 手動アーカイブ
-func ArchiveEvent(db *gorm.DB) gin.HandlerFunc {
+func ArchiveEvent(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 		eventID := c.Param("id")
 
-		var event models.Event
-		if err := db.Where("id = ? AND user_id = ?", eventID, userID).First(&event).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		event, err := store.Events().Get(userID, eventID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 				return
 			}
@@ -193,11 +348,21 @@ func ArchiveEvent(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		before := event
 		event.IsArchived = true
 		now := time.Now()
 		event.ArchivedAt = &now
 
-		if err := db.Save(&event).Error; err != nil {
+		err = store.Transaction(func(tx repository.Store) error {
+			if err := tx.Events().Save(&event); err != nil {
+				return err
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityEvent, event.ID, audit.ActionArchive, before, event); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.EventArchived, event)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to archive event"})
 			return
 		}
@@ -207,18 +372,18 @@ func ArchiveEvent(db *gorm.DB) gin.HandlerFunc {
 }
 
 // UnarchiveEvent 復元
-func UnarchiveEvent(db *gorm.DB) gin.HandlerFunc {
+func UnarchiveEvent(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 		eventID := c.Param("id")
 
-		var event models.Event
-		if err := db.Where("id = ? AND user_id = ?", eventID, userID).First(&event).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		event, err := store.Events().Get(userID, eventID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 				return
 			}
@@ -231,10 +396,17 @@ func UnarchiveEvent(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		before := event
 		event.IsArchived = false
 		event.ArchivedAt = nil
 
-		if err := db.Save(&event).Error; err != nil {
+		err = store.Transaction(func(tx repository.Store) error {
+			if err := tx.Events().Save(&event); err != nil {
+				return err
+			}
+			return tx.Recorder().Audit(auditActor(c), audit.EntityEvent, event.ID, audit.ActionUnarchive, before, event)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unarchive event"})
 			return
 		}
@@ -242,18 +414,20 @@ func UnarchiveEvent(db *gorm.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "Event unarchived successfully"})
 	}
 }
-func ConfirmEvent(db *gorm.DB) gin.HandlerFunc {
+
+// ConfirmEvent 候補日から日時を確定し、既定のリマインダーを作成（確定日時の変更時は再計算）する
+func ConfirmEvent(store repository.Store, reminders jobs.ReminderDefaults) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 		eventID := c.Param("id")
 
-		var event models.Event
-		if err := db.Where("id = ? AND user_id = ?", eventID, userID).First(&event).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		event, err := store.Events().Get(userID, eventID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 				return
 			}
@@ -272,11 +446,6 @@ func ConfirmEvent(db *gorm.DB) gin.HandlerFunc {
 		}
 
 		// Unmarshal confirmed slot
-		type timeSlot struct {
-			StartTime time.Time `json:"start_time"`
-			EndTime   time.Time `json:"end_time"`
-		}
-
 		var confirmed timeSlot
 		if err := json.Unmarshal(updateData.ConfirmedSlot, &confirmed); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid confirmed_slot format"})
@@ -319,15 +488,51 @@ func ConfirmEvent(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		// 確定済み予定との競合チェック（force=true で強制確定）
+		if c.Query("force") != "true" {
+			settings, err := store.UserSettings(userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+				return
+			}
+			confirmedEvents, err := listConfirmedEvents(store, userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check conflicts"})
+				return
+			}
+			conflicts := detectScheduleConflicts(confirmedEvents, confirmed, settings.ConflictBufferMinutes, event.ID)
+			if len(conflicts) > 0 {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":          "Confirmed slot conflicts with other confirmed events",
+					"buffer_minutes": settings.ConflictBufferMinutes,
+					"conflicts":      conflicts,
+				})
+				return
+			}
+		}
+
 		// Optional: normalize status to "confirmed" when confirming
 		if updateData.Status == "" {
 			updateData.Status = "confirmed"
 		}
 
+		before := event
 		event.ConfirmedSlot = updateData.ConfirmedSlot
 		event.Status = updateData.Status
 
-		if err := db.Save(&event).Error; err != nil {
+		err = store.Transaction(func(tx repository.Store) error {
+			if err := tx.Events().Save(&event); err != nil {
+				return err
+			}
+			if err := tx.Recorder().SyncReminders(event, reminders, c.GetString("user_email")); err != nil {
+				return err
+			}
+			if err := tx.Recorder().Audit(auditActor(c), audit.EntityEvent, event.ID, audit.ActionConfirm, before, event); err != nil {
+				return err
+			}
+			return tx.Recorder().Webhook(userID, webhooks.EventConfirmed, event)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm event"})
 			return
 		}
@@ -336,66 +541,57 @@ func ConfirmEvent(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
-// AutoArchiveEvents 確定・キャンセル済みの予定を翌日に自動アーカイブ
-// 基準:
-// - status = confirmed: confirmed_slot.end_time の翌日以降になったもの
-// - status = rejected: updated_at の翌日以降になったもの
-// いずれも is_archived = false が対象
-func AutoArchiveEvents(db *gorm.DB) gin.HandlerFunc {
+// AutoArchiveEvents 確定・キャンセル済みの予定を翌日に自動アーカイブ（呼び出したユーザー分のみ、監査ログを記録）
+// 判定基準は jobs.ArchiveEvents を参照。全ユーザー分は定期ジョブで実行される
+func AutoArchiveEvents(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 
-		// タイムゾーンは東京固定。JSTの当日0時基準で判定
-		// JSONB の confirmed_slot->>'end_time' を timestamptz にキャストし、JSTに変換して比較
-		sql := `
-            UPDATE events
-            SET is_archived = TRUE,
-                archived_at = NOW()
-            WHERE user_id = ?
-              AND is_archived = FALSE
-              AND (
-                    (
-                      status = 'confirmed'
-                      AND confirmed_slot IS NOT NULL
-                      AND (confirmed_slot->>'end_time') IS NOT NULL
-                      AND (((confirmed_slot->>'end_time')::timestamptz AT TIME ZONE 'Asia/Tokyo') < date_trunc('day', (now() AT TIME ZONE 'Asia/Tokyo')))
-                    )
-                 OR (
-                      status = 'rejected'
-                      AND ((updated_at AT TIME ZONE 'Asia/Tokyo') < date_trunc('day', (now() AT TIME ZONE 'Asia/Tokyo')))
-                    )
-              )
-        `
-
-		tx := db.Exec(sql, userID)
-		if tx.Error != nil {
+		updated, err := store.Events().AutoArchive(userID, auditActor(c))
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to auto-archive events"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"updated": tx.RowsAffected})
+		c.JSON(http.StatusOK, gin.H{"updated": updated})
 	}
 }
-func UpdateEventEmailFormat(db *gorm.DB) gin.HandlerFunc {
+
+// UpdateEventEmailFormat 予定のカスタムメールフォーマットと紐付けるメールテンプレートを更新
+func UpdateEventEmailFormat(store repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if db == nil {
+		if store == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
 			return
 		}
 		userID := c.GetString("user_id")
 		eventID := c.Param("id")
 
+		// 送られた項目のみ更新する（email_template_id は null で紐付けを解除）
 		var request struct {
-			CustomEmailFormat string `json:"custom_email_format" validate:"max=2000"`
+			CustomEmailFormat *string         `json:"custom_email_format" validate:"omitempty,max=2000"`
+			EmailTemplateID   json.RawMessage `json:"email_template_id"`
 		}
 		if err := c.ShouldBindJSON(&request); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		updateTemplate := len(request.EmailTemplateID) > 0
+		if request.CustomEmailFormat == nil && !updateTemplate {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "custom_email_format or email_template_id is required"})
+			return
+		}
+		var templateID *string
+		if updateTemplate {
+			if err := json.Unmarshal(request.EmailTemplateID, &templateID); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "email_template_id must be a string or null"})
+				return
+			}
+		}
 
 		// バリデーション
 		validate := validator.New()
@@ -403,11 +599,31 @@ func UpdateEventEmailFormat(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if request.CustomEmailFormat != nil {
+			if err := emailtemplate.Validate(*request.CustomEmailFormat); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email template: " + err.Error()})
+				return
+			}
+		}
+		if templateID != nil {
+			if err := validate.Var(*templateID, "uuid"); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "email_template_id must be a UUID"})
+				return
+			}
+			if err := store.CheckEmailTemplate(userID, *templateID); err != nil {
+				if err == repository.ErrNotFound {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Email template not found"})
+					return
+				}
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch email template"})
+				return
+			}
+		}
 
 		// イベントの存在確認とユーザー権限チェック
-		var event models.Event
-		if err := db.Where("id = ? AND user_id = ?", eventID, userID).First(&event).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		event, err := store.Events().Get(userID, eventID)
+		if err != nil {
+			if err == repository.ErrNotFound {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 			} else {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event"})
@@ -415,13 +631,29 @@ func UpdateEventEmailFormat(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
-		// カスタムフォーマットを更新
-		event.CustomEmailFormat = request.CustomEmailFormat
-		if err := db.Save(&event).Error; err != nil {
+		// カスタムフォーマットとテンプレートの紐付けを更新
+		before := event
+		if request.CustomEmailFormat != nil {
+			event.CustomEmailFormat = *request.CustomEmailFormat
+		}
+		if updateTemplate {
+			event.EmailTemplateID = templateID
+		}
+		err = store.Transaction(func(tx repository.Store) error {
+			if err := tx.Events().Save(&event); err != nil {
+				return err
+			}
+			return tx.Recorder().Audit(auditActor(c), audit.EntityEvent, event.ID, audit.ActionUpdate, before, event)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update email format"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Email format updated successfully", "custom_email_format": event.CustomEmailFormat})
+		c.JSON(http.StatusOK, gin.H{
+			"message":             "Email format updated successfully",
+			"custom_email_format": event.CustomEmailFormat,
+			"email_template_id":   event.EmailTemplateID,
+		})
 	}
 }
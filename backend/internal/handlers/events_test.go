@@ -0,0 +1,445 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+	"career-schedule-api/internal/webhooks"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var jst = time.FixedZone("Asia/Tokyo", 9*60*60)
+
+func TestCreateEvent(t *testing.T) {
+	store := repository.NewMemoryStore()
+	settings := models.NewUserSettings(userA)
+	settings.DefaultInterviewDuration = 45
+	store.SetUserSettings(settings)
+	r := newRouter(store)
+
+	company := seedCompany(store, userA, "Acme", "entry")
+	otherCompany := seedCompany(store, userB, "Other", "entry")
+	const templateID = "33333333-3333-4333-8333-333333333333"
+	const otherTemplateID = "44444444-4444-4444-8444-444444444444"
+	store.AddEmailTemplate(userA, templateID)
+	store.AddEmailTemplate(userB, otherTemplateID)
+
+	body := func(companyID string) map[string]interface{} {
+		return map[string]interface{}{
+			"company_id":   companyID,
+			"company_name": "Acme",
+			"title":        "一次面接",
+			"type":         "interview",
+			"status":       "candidate",
+		}
+	}
+
+	var created models.Event
+	w := do(t, r, userA, http.MethodPost, "/api/v1/events", body(company.ID))
+	expectStatus(t, w, http.StatusCreated, &created)
+	if created.UserID != userA || created.Status != "candidate" {
+		t.Errorf("created = %+v, want a candidate event owned by userA", created)
+	}
+	if created.InterviewDuration != 45 {
+		t.Errorf("interview_duration = %d, want the user's default 45", created.InterviewDuration)
+	}
+	if kinds := webhookKinds(store); len(kinds) != 1 || kinds[0] != webhooks.EventCreated {
+		t.Errorf("webhooks = %v, want [%s]", kinds, webhooks.EventCreated)
+	}
+
+	// 他のユーザーの企業・テンプレートには紐付けられない
+	w = do(t, r, userA, http.MethodPost, "/api/v1/events", body(otherCompany.ID))
+	expectStatus(t, w, http.StatusBadRequest, nil)
+
+	withTemplate := body(company.ID)
+	withTemplate["email_template_id"] = otherTemplateID
+	w = do(t, r, userA, http.MethodPost, "/api/v1/events", withTemplate)
+	expectStatus(t, w, http.StatusBadRequest, nil)
+
+	withTemplate["email_template_id"] = templateID
+	w = do(t, r, userA, http.MethodPost, "/api/v1/events", withTemplate)
+	expectStatus(t, w, http.StatusCreated, nil)
+
+	invalid := body(company.ID)
+	invalid["type"] = "party"
+	w = do(t, r, userA, http.MethodPost, "/api/v1/events", invalid)
+	expectStatus(t, w, http.StatusBadRequest, nil)
+}
+
+func TestUpdateEvent(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+	otherCompany := seedCompany(store, userB, "Other", "entry")
+	event := seedEvent(store, company, "一次面接")
+
+	var updated models.Event
+	w := do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID, map[string]interface{}{
+		"title":    "二次面接",
+		"user_id":  userB,
+		"sequence": 100,
+	})
+	expectStatus(t, w, http.StatusOK, &updated)
+	if updated.Title != "二次面接" || updated.UserID != userA || updated.Sequence != event.Sequence+1 {
+		t.Errorf("updated = %+v, want the new title, same owner and a sequence bumped by the server", updated)
+	}
+	if syncs := store.ReminderSyncs(); len(syncs) != 1 || syncs[0].Event.ID != event.ID {
+		t.Errorf("reminder syncs = %+v, want one for the event", syncs)
+	}
+
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID, map[string]interface{}{"company_id": otherCompany.ID})
+	expectStatus(t, w, http.StatusBadRequest, nil)
+	w = do(t, r, userB, http.MethodPut, "/api/v1/events/"+event.ID, map[string]interface{}{"title": "x"})
+	expectStatus(t, w, http.StatusNotFound, nil)
+	if stored, _ := store.Event(event.ID); stored.Title != "二次面接" || stored.CompanyID != company.ID {
+		t.Errorf("stored = %+v, want unchanged by the rejected updates", stored)
+	}
+}
+
+func TestDeleteEvent(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	event := seedEvent(store, seedCompany(store, userA, "Acme", "entry"), "一次面接")
+
+	w := do(t, r, userB, http.MethodDelete, "/api/v1/events/"+event.ID, nil)
+	expectStatus(t, w, http.StatusNotFound, nil)
+
+	w = do(t, r, userA, http.MethodDelete, "/api/v1/events/"+event.ID, nil)
+	expectStatus(t, w, http.StatusOK, nil)
+	if stored, _ := store.Event(event.ID); !stored.DeletedAt.Valid {
+		t.Errorf("event was not moved to the trash")
+	}
+	audits := store.Audits()
+	if len(audits) != 1 || audits[0].Action != audit.ActionDelete {
+		t.Errorf("audits = %+v, want a delete entry", audits)
+	}
+
+	w = do(t, r, userA, http.MethodDelete, "/api/v1/events/"+event.ID, nil)
+	expectStatus(t, w, http.StatusNotFound, nil)
+
+	var events []models.Event
+	w = do(t, r, userA, http.MethodGet, "/api/v1/events", nil)
+	expectStatus(t, w, http.StatusOK, &events)
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want none", events)
+	}
+}
+
+func TestArchiveAndUnarchiveEvent(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	event := seedEvent(store, seedCompany(store, userA, "Acme", "entry"), "一次面接")
+
+	w := do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/unarchive", nil)
+	expectStatus(t, w, http.StatusBadRequest, nil)
+
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/archive", nil)
+	expectStatus(t, w, http.StatusOK, nil)
+	if stored, _ := store.Event(event.ID); !stored.IsArchived || stored.ArchivedAt == nil {
+		t.Errorf("stored = %+v, want archived", stored)
+	}
+	if !contains(webhookKinds(store), webhooks.EventArchived) {
+		t.Errorf("webhooks = %v, want %s", webhookKinds(store), webhooks.EventArchived)
+	}
+
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/archive", nil)
+	expectStatus(t, w, http.StatusBadRequest, nil)
+
+	var archived []models.Event
+	w = do(t, r, userA, http.MethodGet, "/api/v1/events?is_archived=true", nil)
+	expectStatus(t, w, http.StatusOK, &archived)
+	if len(archived) != 1 {
+		t.Errorf("archived events = %d, want 1", len(archived))
+	}
+
+	w = do(t, r, userB, http.MethodPut, "/api/v1/events/"+event.ID+"/unarchive", nil)
+	expectStatus(t, w, http.StatusNotFound, nil)
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/unarchive", nil)
+	expectStatus(t, w, http.StatusOK, nil)
+	if stored, _ := store.Event(event.ID); stored.IsArchived || stored.ArchivedAt != nil {
+		t.Errorf("stored = %+v, want unarchived", stored)
+	}
+}
+
+// seedConfirmableEvent 60分の予定（候補日時: 10:00〜12:00 JST）
+func seedConfirmableEvent(store *repository.MemoryStore, company models.Company, day time.Time) models.Event {
+	event := seedEvent(store, company, "一次面接")
+	event.CandidateSlots = datatypes.JSON(slotsJSON(day.Add(10*time.Hour), day.Add(12*time.Hour)))
+	return store.PutEvent(event)
+}
+
+func TestConfirmEventSlotValidation(t *testing.T) {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, jst)
+
+	tests := []struct {
+		name   string
+		slot   interface{}
+		status int
+	}{
+		{"not an object", "2026-05-01T10:00:00+09:00", http.StatusBadRequest},
+		{"missing end", map[string]time.Time{"start_time": day.Add(10 * time.Hour)}, http.StatusBadRequest},
+		{"end before start", slotJSON(day.Add(11*time.Hour), day.Add(10*time.Hour)), http.StatusBadRequest},
+		{"duration mismatch", slotJSON(day.Add(10*time.Hour), day.Add(10*time.Hour+30*time.Minute)), http.StatusBadRequest},
+		{"start before candidates", slotJSON(day.Add(9*time.Hour+30*time.Minute), day.Add(10*time.Hour+30*time.Minute)), http.StatusBadRequest},
+		{"start after candidates", slotJSON(day.Add(12*time.Hour+time.Minute), day.Add(13*time.Hour+time.Minute)), http.StatusBadRequest},
+		{"start of candidate", slotJSON(day.Add(10*time.Hour), day.Add(11*time.Hour)), http.StatusOK},
+		{"end may exceed candidate", slotJSON(day.Add(12*time.Hour), day.Add(13*time.Hour)), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := repository.NewMemoryStore()
+			r := newRouter(store)
+			event := seedConfirmableEvent(store, seedCompany(store, userA, "Acme", "entry"), day)
+
+			w := do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/confirm", map[string]interface{}{"confirmed_slot": tt.slot})
+			expectStatus(t, w, tt.status, nil)
+			if stored, _ := store.Event(event.ID); (tt.status == http.StatusOK) != (stored.Status == "confirmed") {
+				t.Errorf("status = %q after %d response", stored.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestConfirmEventWithoutCandidates(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	event := seedEvent(store, seedCompany(store, userA, "Acme", "entry"), "一次面接")
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, jst)
+
+	w := do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/confirm", map[string]interface{}{
+		"confirmed_slot": slotJSON(day.Add(10*time.Hour), day.Add(11*time.Hour)),
+	})
+	expectStatus(t, w, http.StatusBadRequest, nil)
+
+	w = do(t, r, userB, http.MethodPut, "/api/v1/events/"+event.ID+"/confirm", map[string]interface{}{
+		"confirmed_slot": slotJSON(day.Add(10*time.Hour), day.Add(11*time.Hour)),
+	})
+	expectStatus(t, w, http.StatusNotFound, nil)
+}
+
+func TestConfirmEvent(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, jst)
+	event := seedConfirmableEvent(store, seedCompany(store, userA, "Acme", "entry"), day)
+
+	var confirmed models.Event
+	w := do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/confirm", map[string]interface{}{
+		"confirmed_slot": slotJSON(day.Add(10*time.Hour), day.Add(11*time.Hour)),
+	})
+	expectStatus(t, w, http.StatusOK, &confirmed)
+	if confirmed.Status != "confirmed" || len(confirmed.ConfirmedSlot) == 0 {
+		t.Errorf("confirmed = %+v, want status confirmed with a slot", confirmed)
+	}
+
+	audits := store.Audits()
+	if len(audits) != 1 || audits[0].Action != audit.ActionConfirm {
+		t.Errorf("audits = %+v, want a confirm entry", audits)
+	}
+	if kinds := webhookKinds(store); len(kinds) != 1 || kinds[0] != webhooks.EventConfirmed {
+		t.Errorf("webhooks = %v, want [%s]", kinds, webhooks.EventConfirmed)
+	}
+	syncs := store.ReminderSyncs()
+	if len(syncs) != 1 || syncs[0].Event.Status != "confirmed" || syncs[0].Recipient != "user@example.com" || syncs[0].Defaults.Channel != testReminders.Channel {
+		t.Errorf("reminder syncs = %+v, want one for the confirmed event", syncs)
+	}
+
+	// 確定済みの予定自体とは競合しない（確定日時の変更）
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/confirm", map[string]interface{}{
+		"confirmed_slot": slotJSON(day.Add(10*time.Hour+30*time.Minute), day.Add(11*time.Hour+30*time.Minute)),
+	})
+	expectStatus(t, w, http.StatusOK, nil)
+}
+
+func TestConfirmEventConflicts(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, jst)
+	company := seedCompany(store, userA, "Acme", "entry")
+	event := seedConfirmableEvent(store, company, day)
+
+	// 13:00〜14:00 に確定済み（バッファ30分で 12:30〜14:30 と競合する）
+	existing := seedEvent(store, seedCompany(store, userA, "Other", "entry"), "最終面接")
+	existing.Status = "confirmed"
+	existing.ConfirmedSlot = datatypes.JSON(slotJSON(day.Add(13*time.Hour), day.Add(14*time.Hour)))
+	existing = store.PutEvent(existing)
+
+	// アーカイブ済み・ゴミ箱・他のユーザーの予定は競合の対象外
+	archived := existing
+	archived.ID = ""
+	archived.IsArchived = true
+	archived.ConfirmedSlot = datatypes.JSON(slotJSON(day.Add(10*time.Hour), day.Add(11*time.Hour)))
+	store.PutEvent(archived)
+	trashed := archived
+	trashed.IsArchived = false
+	trashed.DeletedAt = gorm.DeletedAt{Time: day, Valid: true}
+	store.PutEvent(trashed)
+	otherUser := archived
+	otherUser.IsArchived = false
+	otherUser.UserID = userB
+	store.PutEvent(otherUser)
+
+	var conflict struct {
+		BufferMinutes int `json:"buffer_minutes"`
+		Conflicts     []struct {
+			EventID        string `json:"event_id"`
+			OverlapMinutes int    `json:"overlap_minutes"`
+		} `json:"conflicts"`
+	}
+	conflicting := map[string]interface{}{"confirmed_slot": slotJSON(day.Add(12*time.Hour), day.Add(13*time.Hour))}
+	w := do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/confirm", conflicting)
+	expectStatus(t, w, http.StatusConflict, &conflict)
+	if conflict.BufferMinutes != models.DefaultConflictBufferMinutes || len(conflict.Conflicts) != 1 ||
+		conflict.Conflicts[0].EventID != existing.ID || conflict.Conflicts[0].OverlapMinutes != 30 {
+		t.Errorf("conflict = %+v, want a 30 minute overlap with %s", conflict, existing.ID)
+	}
+	if stored, _ := store.Event(event.ID); stored.Status != "candidate" {
+		t.Errorf("status = %q, want unchanged after a conflict", stored.Status)
+	}
+
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/confirm?force=true", conflicting)
+	expectStatus(t, w, http.StatusOK, nil)
+
+	// バッファを0にすると隣接する枠は競合しない（12:00〜13:00 は確定済み）
+	settings := models.NewUserSettings(userA)
+	settings.ConflictBufferMinutes = 0
+	store.SetUserSettings(settings)
+	adjacent := seedConfirmableEvent(store, company, day)
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+adjacent.ID+"/confirm", map[string]interface{}{
+		"confirmed_slot": slotJSON(day.Add(11*time.Hour), day.Add(12*time.Hour)),
+	})
+	expectStatus(t, w, http.StatusOK, nil)
+}
+
+// 対象の判定（autoArchiveSQL）は MemoryStore では再現しないため、ここではハンドラーが呼び出したユーザーの分だけを
+// アーカイブして監査ログを記録することを確認する。判定基準は internal/jobs の結合テスト（-tags integration）で確認する
+func TestAutoArchiveEvents(t *testing.T) {
+	now := time.Date(2026, 4, 10, 12, 0, 0, 0, jst)
+	store := repository.NewMemoryStore()
+	store.Now = func() time.Time { return now }
+	store.AutoArchiveDue = func(e models.Event) bool { return e.Status == "rejected" }
+	r := newRouter(store)
+
+	put := func(company models.Company, status string) models.Event {
+		event := seedEvent(store, company, status)
+		event.Status = status
+		return store.PutEvent(event)
+	}
+	due := put(seedCompany(store, userA, "Acme", "entry"), "rejected")
+	notDue := put(seedCompany(store, userA, "Beta", "entry"), "confirmed")
+	otherUser := put(seedCompany(store, userB, "Other", "entry"), "rejected")
+
+	var response struct {
+		Updated int64 `json:"updated"`
+	}
+	w := do(t, r, userA, http.MethodPut, "/api/v1/events/auto-archive/run", nil)
+	expectStatus(t, w, http.StatusOK, &response)
+	if response.Updated != 1 {
+		t.Errorf("updated = %d, want 1", response.Updated)
+	}
+	if stored, _ := store.Event(due.ID); !stored.IsArchived || stored.ArchivedAt == nil || !stored.ArchivedAt.Equal(now) {
+		t.Errorf("due event = archived %v at %v, want archived at %v", stored.IsArchived, stored.ArchivedAt, now)
+	}
+	if stored, _ := store.Event(notDue.ID); stored.IsArchived {
+		t.Errorf("event that is not due was archived")
+	}
+	if stored, _ := store.Event(otherUser.ID); stored.IsArchived {
+		t.Errorf("another user's event was archived")
+	}
+
+	audits := store.Audits()
+	if len(audits) != 1 {
+		t.Fatalf("audits = %d, want one per archived event", len(audits))
+	}
+	if a := audits[0]; a.Action != audit.ActionArchive || a.EntityType != audit.EntityEvent || a.EntityID != due.ID || a.Actor.UserID != userA {
+		t.Errorf("audit = %+v, want an archive of %s by %s", a, due.ID, userA)
+	}
+	if len(store.Webhooks()) != 0 {
+		t.Errorf("webhooks = %v, want none", webhookKinds(store))
+	}
+}
+
+func TestUpdateEventEmailFormat(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	event := seedEvent(store, seedCompany(store, userA, "Acme", "entry"), "一次面接")
+	const templateID = "33333333-3333-4333-8333-333333333333"
+
+	w := do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/email-format", map[string]interface{}{"email_template_id": templateID})
+	expectStatus(t, w, http.StatusBadRequest, nil)
+
+	store.AddEmailTemplate(userA, templateID)
+	var response struct {
+		EmailTemplateID *string `json:"email_template_id"`
+	}
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/email-format", map[string]interface{}{"email_template_id": templateID})
+	expectStatus(t, w, http.StatusOK, &response)
+	if response.EmailTemplateID == nil || *response.EmailTemplateID != templateID {
+		t.Errorf("email_template_id = %v, want %s", response.EmailTemplateID, templateID)
+	}
+
+	// 送らなかった項目は変更しない
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/email-format", map[string]interface{}{"custom_email_format": "{{title}}"})
+	expectStatus(t, w, http.StatusOK, nil)
+	if stored, _ := store.Event(event.ID); stored.CustomEmailFormat != "{{title}}" || stored.EmailTemplateID == nil || *stored.EmailTemplateID != templateID {
+		t.Errorf("stored = %q / %v, want the new format and the template kept", stored.CustomEmailFormat, stored.EmailTemplateID)
+	}
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/email-format", map[string]interface{}{"email_template_id": nil})
+	expectStatus(t, w, http.StatusOK, nil)
+	if stored, _ := store.Event(event.ID); stored.CustomEmailFormat != "{{title}}" || stored.EmailTemplateID != nil {
+		t.Errorf("stored = %q / %v, want the format kept and the template unlinked", stored.CustomEmailFormat, stored.EmailTemplateID)
+	}
+	w = do(t, r, userA, http.MethodPut, "/api/v1/events/"+event.ID+"/email-format", map[string]interface{}{})
+	expectStatus(t, w, http.StatusBadRequest, nil)
+
+	w = do(t, r, userB, http.MethodPut, "/api/v1/events/"+event.ID+"/email-format", map[string]interface{}{"custom_email_format": "x"})
+	expectStatus(t, w, http.StatusNotFound, nil)
+}
+
+func TestGetEventsFilters(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+	other := seedCompany(store, userA, "Other", "entry")
+
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, jst)
+	inRange := seedEvent(store, company, "一次面接")
+	inRange.Status = "confirmed"
+	inRange.ConfirmedSlot = datatypes.JSON(slotJSON(day.Add(10*time.Hour), day.Add(11*time.Hour)))
+	store.PutEvent(inRange)
+	outOfRange := seedEvent(store, company, "二次面接")
+	outOfRange.Status = "confirmed"
+	outOfRange.ConfirmedSlot = datatypes.JSON(slotJSON(day.AddDate(0, 0, 7), day.AddDate(0, 0, 7).Add(time.Hour)))
+	store.PutEvent(outOfRange)
+	seedEvent(store, other, "説明会")
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 3},
+		{"?company_id=" + company.ID, 2},
+		{"?status=confirmed", 2},
+		{"?from=2026-05-01&to=2026-05-01", 1},
+		{"?from=2026-05-02", 1},
+	}
+	for _, tt := range tests {
+		var events []models.Event
+		w := do(t, r, userA, http.MethodGet, "/api/v1/events"+tt.query, nil)
+		expectStatus(t, w, http.StatusOK, &events)
+		if len(events) != tt.want {
+			titles, _ := json.Marshal(events)
+			t.Errorf("GET /events%s = %d events, want %d (%s)", tt.query, len(events), tt.want, titles)
+		}
+	}
+
+	w := do(t, r, userA, http.MethodGet, "/api/v1/events?from=05-01", nil)
+	expectStatus(t, w, http.StatusBadRequest, nil)
+}
@@ -0,0 +1,164 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"career-schedule-api/internal/handlers"
+	"career-schedule-api/internal/jobs"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// テスト用のユーザー（user_id は UUID 形式）
+const (
+	userA = "11111111-1111-4111-8111-111111111111"
+	userB = "22222222-2222-4222-8222-222222222222"
+)
+
+// testReminders ConfirmEvent / UpdateEvent に渡すリマインダーの既定値
+var testReminders = jobs.NewReminderDefaults([]int{1440, 60}, models.ReminderChannelLog)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newRouter MemoryStore を使うルーター（認証の代わりに X-Test-User ヘッダーのユーザーとして扱う）
+func newRouter(store repository.Store) *gin.Engine {
+	r := gin.New()
+	api := r.Group("/api/v1", func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-Test-User"))
+		c.Set("user_email", "user@example.com")
+	})
+
+	stagePolicy := handlers.NewStagePolicy(false)
+	companies := api.Group("/companies")
+	companies.GET("", handlers.GetCompanies(store))
+	companies.POST("", handlers.CreateCompany(store))
+	companies.GET("/:id", handlers.GetCompany(store))
+	companies.PUT("/:id", handlers.UpdateCompany(store, stagePolicy))
+	companies.DELETE("/:id", handlers.DeleteCompany(store))
+	companies.PUT("/:id/archive", handlers.ArchiveCompany(store))
+	companies.PUT("/:id/unarchive", handlers.UnarchiveCompany(store))
+
+	events := api.Group("/events")
+	events.GET("", handlers.GetEvents(store))
+	events.POST("", handlers.CreateEvent(store))
+	events.GET("/:id", handlers.GetEvent(store))
+	events.PUT("/:id", handlers.UpdateEvent(store, testReminders))
+	events.DELETE("/:id", handlers.DeleteEvent(store))
+	events.PUT("/:id/confirm", handlers.ConfirmEvent(store, testReminders))
+	events.PUT("/:id/email-format", handlers.UpdateEventEmailFormat(store))
+	events.PUT("/:id/archive", handlers.ArchiveEvent(store))
+	events.PUT("/:id/unarchive", handlers.UnarchiveEvent(store))
+	events.PUT("/auto-archive/run", handlers.AutoArchiveEvents(store))
+	events.POST("/conflicts", handlers.CheckEventConflicts(store))
+	events.POST("/import", handlers.ImportEvents(store, testReminders))
+	return r
+}
+
+// do userID としてリクエストを送る（body が nil でなければ JSON にする）
+func do(t *testing.T, r http.Handler, userID, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", userID)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// expectStatus ステータスコードを確認し、レスポンスを v に読み込む（v が nil なら読み込まない）
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d (body: %s)", w.Code, status, w.Body.String())
+	}
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("decode response: %v (body: %s)", err, w.Body.String())
+		}
+	}
+}
+
+// seedCompany userID の企業を作成する
+func seedCompany(store *repository.MemoryStore, userID, name, stage string) models.Company {
+	now := time.Now()
+	return store.PutCompany(models.Company{
+		UserID:       userID,
+		Name:         name,
+		CurrentStage: stage,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	})
+}
+
+// seedEvent company の予定を作成する（候補日時・確定日時は未設定）
+func seedEvent(store *repository.MemoryStore, company models.Company, title string) models.Event {
+	now := time.Now()
+	return store.PutEvent(models.Event{
+		UserID:            company.UserID,
+		CompanyID:         company.ID,
+		CompanyName:       company.Name,
+		Title:             title,
+		Type:              "interview",
+		Status:            "candidate",
+		InterviewDuration: 60,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	})
+}
+
+// slotJSON 1枠分の confirmed_slot
+func slotJSON(start, end time.Time) json.RawMessage {
+	b, _ := json.Marshal(map[string]time.Time{"start_time": start, "end_time": end})
+	return b
+}
+
+// slotsJSON candidate_slots（start, end の組を並べて指定する）
+func slotsJSON(times ...time.Time) json.RawMessage {
+	slots := []map[string]time.Time{}
+	for i := 0; i+1 < len(times); i += 2 {
+		slots = append(slots, map[string]time.Time{"start_time": times[i], "end_time": times[i+1]})
+	}
+	b, _ := json.Marshal(slots)
+	return b
+}
+
+// webhookKinds 記録された Webhook の種別（記録順）
+func webhookKinds(store *repository.MemoryStore) []string {
+	kinds := []string{}
+	for _, w := range store.Webhooks() {
+		kinds = append(kinds, w.Kind)
+	}
+	return kinds
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHandlersWithoutStore(t *testing.T) {
+	r := newRouter(nil)
+	w := do(t, r, userA, http.MethodGet, "/api/v1/companies", nil)
+	expectStatus(t, w, http.StatusServiceUnavailable, nil)
+	w = do(t, r, userA, http.MethodGet, "/api/v1/events", nil)
+	expectStatus(t, w, http.StatusServiceUnavailable, nil)
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/ical"
+	"career-schedule-api/internal/jobs"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+	"career-schedule-api/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/datatypes"
+)
+
+const (
+	// 取り込めるファイルサイズと予定数の上限
+	maxImportFileBytes = 1 << 20
+	maxImportEvents    = 200
+)
+
+// importPreview 取り込み対象の1件（dry_run 時のプレビュー）
+type importPreview struct {
+	UID       string             `json:"uid"`
+	Title     string             `json:"title"`
+	Location  string             `json:"location"`
+	Status    string             `json:"status"`
+	Slot      timeSlot           `json:"slot"`
+	Conflicts []scheduleConflict `json:"conflicts"`
+}
+
+// importSkipped 取り込まなかった VEVENT と理由
+type importSkipped struct {
+	UID    string `json:"uid"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+// truncateRunes 文字数上限を超える部分を切り詰める
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
+
+// ImportEvents iCalendar ファイルの VEVENT を指定企業の予定として取り込む
+// multipart/form-data:
+//   - file: .ics ファイル（必須）
+//   - company_id: 紐付ける企業（必須）
+//   - type: 予定種別（省略時 interview）
+//   - mode: candidate（候補日として登録、既定）| confirmed（確定済みとして登録）
+//   - interview_duration: candidate モードでの面接時間（分、省略時はユーザー設定のデフォルト）
+//   - dry_run: true の場合は保存せずプレビューのみ返す
+//
+// キャンセル済み・日時や TZID が読み込めない・検証に通らない（終日の予定や長すぎる予定など）VEVENT は取り込まず、
+// skipped（件数）と skipped_events（UID・タイトル・理由）で返す
+// confirmed モードで登録した予定には既定のリマインダーを作成する
+func ImportEvents(store repository.Store, reminders jobs.ReminderDefaults) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileBytes+64*1024)
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		if fileHeader.Size > maxImportFileBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+			return
+		}
+
+		validate := validator.New()
+		eventType := c.DefaultPostForm("type", "interview")
+		if err := validate.Var(eventType, "oneof=meeting interview info_session group_discussion final_interview"); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type"})
+			return
+		}
+		mode := c.DefaultPostForm("mode", "candidate")
+		if mode != "candidate" && mode != "confirmed" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be candidate or confirmed"})
+			return
+		}
+		interviewDuration := 0
+		if v := c.PostForm("interview_duration"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interview_duration"})
+				return
+			}
+			interviewDuration = n
+		}
+		dryRun := c.PostForm("dry_run") == "true"
+
+		// 企業の所有者確認
+		company, err := store.Companies().Get(userID, c.PostForm("company_id"))
+		if err != nil {
+			if err == repository.ErrNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+			return
+		}
+		defer file.Close()
+
+		settings, err := store.UserSettings(userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+
+		if interviewDuration == 0 {
+			interviewDuration = settings.DefaultInterviewDuration
+		}
+
+		// タイムゾーン指定のない日時はユーザーのタイムゾーンとして扱う
+		calendar, err := ical.Decode(file, settings.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid iCalendar file: " + err.Error()})
+			return
+		}
+
+		// 競合判定の対象は1度だけ読み込み、VEVENT ごとに照合する
+		confirmedEvents, err := listConfirmedEvents(store, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check conflicts"})
+			return
+		}
+
+		previews := []importPreview{}
+		events := []models.Event{}
+		skipped := []importSkipped{}
+		skip := func(ve ical.Event, reason string) {
+			skipped = append(skipped, importSkipped{UID: ve.UID, Title: ve.Summary, Reason: reason})
+		}
+		for _, ve := range calendar.Events {
+			slot := timeSlot{StartTime: ve.Start, EndTime: ve.End}
+			if ve.Err != nil {
+				skip(ve, ve.Err.Error())
+				continue
+			}
+			if ve.Status == "CANCELLED" {
+				skip(ve, "cancelled")
+				continue
+			}
+			if !slot.valid() {
+				skip(ve, "invalid start or end time")
+				continue
+			}
+			if len(events) >= maxImportEvents {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Too many events in file (max " + strconv.Itoa(maxImportEvents) + ")"})
+				return
+			}
+
+			title := strings.TrimSpace(ve.Summary)
+			if title == "" {
+				title = company.Name + " 予定"
+			}
+
+			// 入力値サニタイゼーション（CreateEvent と同様）
+			event := models.Event{
+				CompanyID:   company.ID,
+				UserID:      userID,
+				CompanyName: company.Name,
+				Title:       html.EscapeString(truncateRunes(title, 200)),
+				Type:        eventType,
+				Status:      mode,
+				Location:    html.EscapeString(truncateRunes(strings.TrimSpace(ve.Location), 200)),
+				Notes:       html.EscapeString(truncateRunes(strings.TrimSpace(ve.Description), 1000)),
+			}
+
+			slotJSON, err := json.Marshal(slot)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode slot"})
+				return
+			}
+			candidates, err := json.Marshal([]timeSlot{slot})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode slot"})
+				return
+			}
+			event.CandidateSlots = datatypes.JSON(candidates)
+			event.InterviewDuration = interviewDuration
+			if mode == "confirmed" {
+				event.ConfirmedSlot = datatypes.JSON(slotJSON)
+				event.InterviewDuration = int(slot.EndTime.Sub(slot.StartTime).Minutes())
+			}
+
+			if err := validate.Struct(&event); err != nil {
+				skip(ve, "Validation failed: "+err.Error())
+				continue
+			}
+
+			conflicts := detectScheduleConflicts(confirmedEvents, slot, settings.ConflictBufferMinutes, "")
+			previews = append(previews, importPreview{
+				UID:       ve.UID,
+				Title:     event.Title,
+				Location:  event.Location,
+				Status:    event.Status,
+				Slot:      slot,
+				Conflicts: conflicts,
+			})
+			events = append(events, event)
+		}
+
+		if dryRun || len(events) == 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"dry_run":        dryRun,
+				"count":          len(events),
+				"skipped":        len(skipped),
+				"skipped_events": skipped,
+				"events":         previews,
+			})
+			return
+		}
+
+		err = store.Transaction(func(tx repository.Store) error {
+			for i := range events {
+				if err := tx.Events().Create(&events[i]); err != nil {
+					return err
+				}
+				event := events[i]
+				if err := tx.Recorder().SyncReminders(event, reminders, c.GetString("user_email")); err != nil {
+					return err
+				}
+				if err := tx.Recorder().Audit(auditActor(c), audit.EntityEvent, event.ID, audit.ActionCreate, nil, event); err != nil {
+					return err
+				}
+				if err := tx.Recorder().Webhook(userID, webhooks.EventCreated, event); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import events"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"dry_run":        false,
+			"count":          len(events),
+			"skipped":        len(skipped),
+			"skipped_events": skipped,
+			"events":         previews,
+			"created":        events,
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/repository"
+	"career-schedule-api/internal/webhooks"
+)
+
+// importICS .ics ファイルと fields を multipart/form-data で取り込み API に送る
+func importICS(t *testing.T, r http.Handler, userID, ics string, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fw, err := mw.CreateFormFile("file", "calendar.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(ics))
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/events/import", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("X-Test-User", userID)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+const importCalendar = "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\nUID:conflict\r\nSUMMARY:一次面接\r\nDTSTART;TZID=Tokyo Standard Time:20260501T123000\r\nDTEND;TZID=Tokyo Standard Time:20260501T133000\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:free\r\nSUMMARY:説明会\r\nDTSTART:20260502T010000Z\r\nDURATION:PT1H\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:cancelled\r\nSTATUS:CANCELLED\r\nDTSTART:20260503T010000Z\r\nDURATION:PT1H\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:bad-date\r\nDTSTART:2026-05-04\r\nDURATION:PT1H\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:unknown-tzid\r\nDTSTART;TZID=Nowhere:20260505T100000\r\nDURATION:PT1H\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+type importResponse struct {
+	Count         int `json:"count"`
+	Skipped       int `json:"skipped"`
+	SkippedEvents []struct {
+		UID    string `json:"uid"`
+		Reason string `json:"reason"`
+	} `json:"skipped_events"`
+	Events []struct {
+		UID       string `json:"uid"`
+		Conflicts []struct {
+			EventID string `json:"event_id"`
+		} `json:"conflicts"`
+	} `json:"events"`
+	Created []models.Event `json:"created"`
+}
+
+func TestImportEventsDryRun(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, jst)
+	company := seedCompany(store, userA, "Acme", "entry")
+	existing := seedConfirmedEvent(store, company, day.Add(13*time.Hour), day.Add(14*time.Hour))
+
+	var got importResponse
+	w := importICS(t, r, userA, importCalendar, map[string]string{"company_id": company.ID, "dry_run": "true"})
+	expectStatus(t, w, http.StatusOK, &got)
+	if got.Count != 2 || len(got.Events) != 2 || len(got.Created) != 0 {
+		t.Fatalf("response = %+v, want two previews and nothing created", got)
+	}
+	if conflicts := got.Events[0].Conflicts; got.Events[0].UID != "conflict" || len(conflicts) != 1 || conflicts[0].EventID != existing.ID {
+		t.Errorf("events[0] = %+v, want a conflict with %s", got.Events[0], existing.ID)
+	}
+	if got.Events[1].UID != "free" || len(got.Events[1].Conflicts) != 0 {
+		t.Errorf("events[1] = %+v, want free without conflicts", got.Events[1])
+	}
+
+	reasons := map[string]string{}
+	for _, s := range got.SkippedEvents {
+		reasons[s.UID] = s.Reason
+	}
+	want := map[string]string{"cancelled": "cancelled", "bad-date": "invalid date-time", "unknown-tzid": "unknown TZID"}
+	if got.Skipped != len(want) {
+		t.Errorf("skipped = %d, want %d", got.Skipped, len(want))
+	}
+	for uid, reason := range want {
+		if !strings.Contains(reasons[uid], reason) {
+			t.Errorf("reason for %s = %q, want %q", uid, reasons[uid], reason)
+		}
+	}
+	if events, _ := store.Events().List(userA, repository.EventFilter{}, repository.Page{}); len(events) != 1 {
+		t.Errorf("events = %d, want only the existing one after a dry run", len(events))
+	}
+}
+
+func TestImportEventsConfirmed(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+
+	var got importResponse
+	w := importICS(t, r, userA, importCalendar, map[string]string{"company_id": company.ID, "mode": "confirmed", "type": "info_session"})
+	expectStatus(t, w, http.StatusCreated, &got)
+	if got.Count != 2 || len(got.Created) != 2 {
+		t.Fatalf("response = %+v, want two created events", got)
+	}
+	for _, created := range got.Created {
+		stored, ok := store.Event(created.ID)
+		if !ok || stored.UserID != userA || stored.Status != "confirmed" || stored.Type != "info_session" || len(stored.ConfirmedSlot) == 0 {
+			t.Errorf("stored = %+v (found %v), want a confirmed info_session of userA", stored, ok)
+		}
+	}
+	if audits := store.Audits(); len(audits) != 2 || audits[0].Action != audit.ActionCreate {
+		t.Errorf("audits = %+v, want two create entries", audits)
+	}
+	if kinds := webhookKinds(store); len(kinds) != 2 || kinds[0] != webhooks.EventCreated {
+		t.Errorf("webhooks = %v, want two %s", kinds, webhooks.EventCreated)
+	}
+	if syncs := store.ReminderSyncs(); len(syncs) != 2 || syncs[0].Recipient != "user@example.com" {
+		t.Errorf("reminder syncs = %+v, want one per created event", syncs)
+	}
+}
+
+func TestImportEventsRequestValidation(t *testing.T) {
+	store := repository.NewMemoryStore()
+	r := newRouter(store)
+	company := seedCompany(store, userA, "Acme", "entry")
+	other := seedCompany(store, userB, "Other", "entry")
+
+	tests := []struct {
+		name   string
+		fields map[string]string
+		status int
+	}{
+		{"invalid type", map[string]string{"company_id": company.ID, "type": "party"}, http.StatusBadRequest},
+		{"invalid mode", map[string]string{"company_id": company.ID, "mode": "tentative"}, http.StatusBadRequest},
+		{"other user's company", map[string]string{"company_id": other.ID}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := importICS(t, r, userA, importCalendar, tt.fields)
+			expectStatus(t, w, tt.status, nil)
+		})
+	}
+	if events, _ := store.Events().List(userA, repository.EventFilter{}, repository.Page{}); len(events) != 0 {
+		t.Errorf("events = %d, want none", len(events))
+	}
+}
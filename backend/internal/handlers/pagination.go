@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"career-schedule-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// listParams 一覧APIの共通パラメータ
+//   - limit / cursor を指定するとレスポンスが {items, next_cursor, total} の形式になる
+//   - 指定しない場合は従来どおり配列をそのまま返す
+//   - 並び替え・カーソルは repository.Page の ApplyCursor / OrderClause でクエリに適用する
+type listParams struct {
+	repository.Page
+	Paginated bool
+}
+
+// parseListParams sort / order / limit / cursor を解釈する
+func parseListParams(c *gin.Context, keys map[string]repository.SortKey, defaultSort string) (listParams, error) {
+	params := listParams{Page: repository.Page{Limit: defaultPageLimit, Desc: true}}
+
+	sortName := c.DefaultQuery("sort", defaultSort)
+	key, ok := keys[sortName]
+	if !ok {
+		return params, errors.New("Invalid sort key: " + sortName)
+	}
+	params.SortName = sortName
+	params.Sort = key
+
+	switch c.DefaultQuery("order", "desc") {
+	case "desc":
+	case "asc":
+		params.Desc = false
+	default:
+		return params, errors.New("order must be asc or desc")
+	}
+
+	if v, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return params, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageLimit))
+		}
+		params.Limit = limit
+		params.Paginated = true
+	}
+
+	if v, ok := c.GetQuery("cursor"); ok {
+		params.Paginated = true
+		if v != "" {
+			cursor, err := decodeCursor(v)
+			if err != nil {
+				return params, errors.New("Invalid cursor")
+			}
+			if key.Time {
+				if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+					return params, errors.New("Invalid cursor")
+				}
+			}
+			params.Cursor = cursor
+		}
+	}
+
+	return params, nil
+}
+
+func encodeCursor(value, id string) string {
+	b, _ := json.Marshal(repository.Cursor{Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*repository.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var cursor repository.Cursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.ID == "" {
+		return nil, errors.New("missing id")
+	}
+	return &cursor, nil
+}
+
+// cursorValue 並び替えキーの値をカーソル用の文字列にする
+func cursorValue(v interface{}) string {
+	switch value := v.(type) {
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	case string:
+		return value
+	default:
+		return ""
+	}
+}
+
+// pageResponse ページネーション時のレスポンス
+type pageResponse struct {
+	Items      interface{} `json:"items"`
+	NextCursor *string     `json:"next_cursor"`
+	Total      int64       `json:"total"`
+}
+
+// parseBoolQuery true / false のクエリパラメータ（未指定なら nil）
+func parseBoolQuery(c *gin.Context, key string) (*bool, error) {
+	v, ok := c.GetQuery(key)
+	if !ok || v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, errors.New(key + " must be true or false")
+	}
+	return &b, nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetEventReminders 予定のリマインダーと送信記録
+func GetEventReminders(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		eventID := c.Param("id")
+
+		var event models.Event
+		if err := db.Select("id").Where("id = ? AND user_id = ?", eventID, userID).First(&event).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event"})
+			return
+		}
+
+		var reminders []models.Reminder
+		if err := db.Where("event_id = ? AND user_id = ?", event.ID, userID).Order("remind_at ASC").Find(&reminders).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminders"})
+			return
+		}
+
+		ids := make([]string, 0, len(reminders))
+		for _, r := range reminders {
+			ids = append(ids, r.ID)
+		}
+		deliveries := []models.ReminderDelivery{}
+		if len(ids) > 0 {
+			if err := db.Where("reminder_id IN ?", ids).Order("created_at ASC").Find(&deliveries).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reminder deliveries"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"reminders":  reminders,
+			"deliveries": deliveries,
+		})
+	}
+}
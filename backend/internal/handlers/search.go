@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"html"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	maxSearchQueryRunes = 100
+	maxSearchTerms      = 5
+	defaultSearchLimit  = 20
+	maxSearchLimit      = 100
+
+	// ランク付け前にテーブルごとに取得する候補数の上限
+	searchCandidateLimit = 200
+
+	// スニペットとしてマッチ箇所の前後に残す文字数
+	snippetContextRunes = 30
+)
+
+// searchField 検索対象の列と、ランク付けでの重み
+type searchField struct {
+	Column string
+	Weight float64
+}
+
+var companySearchFields = []searchField{
+	{Column: "name", Weight: 3},
+	{Column: "industry", Weight: 2},
+	{Column: "position", Weight: 2},
+	{Column: "notes", Weight: 1},
+}
+
+var eventSearchFields = []searchField{
+	{Column: "title", Weight: 3},
+	{Column: "location", Weight: 2},
+	{Column: "notes", Weight: 1},
+}
+
+// searchResult 検索結果の1件（企業と予定を同じ形で返す）
+type searchResult struct {
+	Type         string    `json:"type"` // company | event
+	ID           string    `json:"id"`
+	CompanyID    string    `json:"company_id"`
+	Title        string    `json:"title"`
+	Subtitle     string    `json:"subtitle"`
+	IsArchived   bool      `json:"is_archived"`
+	MatchedField string    `json:"matched_field"`
+	Snippet      string    `json:"snippet"` // HTMLエスケープ済み、マッチ箇所は <mark> で囲む
+	Rank         float64   `json:"rank"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type companySearchRow struct {
+	ID         string
+	Name       string
+	Industry   string
+	Position   string
+	Notes      string
+	IsArchived bool
+	UpdatedAt  time.Time
+	FtsRank    float64
+}
+
+type eventSearchRow struct {
+	ID          string
+	CompanyID   string
+	CompanyName string
+	Title       string
+	Location    string
+	Notes       string
+	IsArchived  bool
+	UpdatedAt   time.Time
+	FtsRank     float64
+}
+
+// searchVectorExpr 全文検索用の tsvector 式（migrations/003_add_search_indexes.up.sql のインデックスと一致させる）
+func searchVectorExpr(fields []searchField) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		parts = append(parts, "coalesce("+f.Column+", '')")
+	}
+	return "to_tsvector('simple', " + strings.Join(parts, " || ' ' || ") + ")"
+}
+
+// escapeLike LIKE のワイルドカードをエスケープ
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// searchQuery 全文検索、または全ての語がいずれかの列に部分一致する行を探す
+// 日本語は simple 構成で単語分割されないため、部分一致（トライグラムインデックス）で補う
+func searchQuery(db *gorm.DB, table string, fields []searchField, userID, q string, terms []string, isArchived *bool) *gorm.DB {
+	vector := searchVectorExpr(fields)
+	query := db.Table(table).
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Select("*, ts_rank("+vector+", plainto_tsquery('simple', ?)) AS fts_rank", q)
+	if isArchived != nil {
+		query = query.Where("is_archived = ?", *isArchived)
+	}
+
+	conditions := []string{vector + " @@ plainto_tsquery('simple', ?)"}
+	args := []interface{}{q}
+
+	termConditions := make([]string, 0, len(terms))
+	for _, term := range terms {
+		// 保存時にHTMLエスケープしているため、検索語も合わせる
+		pattern := "%" + escapeLike(html.EscapeString(term)) + "%"
+		columns := make([]string, 0, len(fields))
+		for _, f := range fields {
+			columns = append(columns, f.Column+" ILIKE ?")
+			args = append(args, pattern)
+		}
+		termConditions = append(termConditions, "("+strings.Join(columns, " OR ")+")")
+	}
+	conditions = append(conditions, "("+strings.Join(termConditions, " AND ")+")")
+
+	return query.Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Order("fts_rank DESC, updated_at DESC").
+		Limit(searchCandidateLimit)
+}
+
+// lowerRunes 文字数を変えずに小文字化（位置を元の文字列と対応させるため）
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+func indexRunes(haystack, needle []rune, from int) int {
+	for i := from; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchRanges 各検索語のマッチ位置（ルーン単位、重なりは結合済み）
+func matchRanges(text string, terms []string) [][2]int {
+	lower := lowerRunes(text)
+	var ranges [][2]int
+	for _, term := range terms {
+		needle := lowerRunes(term)
+		if len(needle) == 0 {
+			continue
+		}
+		for i := indexRunes(lower, needle, 0); i >= 0; i = indexRunes(lower, needle, i+len(needle)) {
+			ranges = append(ranges, [2]int{i, i + len(needle)})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	merged := [][2]int{}
+	for _, r := range ranges {
+		if len(merged) > 0 && r[0] <= merged[len(merged)-1][1] {
+			if r[1] > merged[len(merged)-1][1] {
+				merged[len(merged)-1][1] = r[1]
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// highlight マッチ箇所の前後を切り出し、HTMLエスケープした上で <mark> で囲む
+func highlight(text string, ranges [][2]int) string {
+	runes := []rune(text)
+	start, end := 0, len(runes)
+	if len(ranges) > 0 {
+		if ranges[0][0] > snippetContextRunes {
+			start = ranges[0][0] - snippetContextRunes
+		}
+		if last := ranges[0][1] + snippetContextRunes*2; last < end {
+			end = last
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, r := range ranges {
+		if r[1] <= start || r[0] >= end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:r[0]])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[r[0]:r[1]])))
+		b.WriteString("</mark>")
+		pos = r[1]
+	}
+	if pos < end {
+		b.WriteString(html.EscapeString(string(runes[pos:end])))
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// scoreFields 列ごとのマッチ数と重みからスコアを計算し、スニペットを作る列を選ぶ
+func scoreFields(fields []searchField, values map[string]string, terms []string) (float64, string, string) {
+	score := 0.0
+	matchedField, snippet := "", ""
+	for _, f := range fields {
+		text := html.UnescapeString(values[f.Column])
+		ranges := matchRanges(text, terms)
+		if len(ranges) == 0 {
+			continue
+		}
+		score += f.Weight * float64(len(ranges))
+		if matchedField == "" {
+			matchedField = f.Column
+			snippet = highlight(text, ranges)
+		}
+	}
+	return score, matchedField, snippet
+}
+
+// Search 企業・予定・メモの横断検索
+// クエリ: q（必須）、type（company / event）、is_archived、limit
+func Search(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+			return
+		}
+		if len([]rune(q)) > maxSearchQueryRunes {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "q must be at most " + strconv.Itoa(maxSearchQueryRunes) + " characters"})
+			return
+		}
+		terms := strings.Fields(q)
+		if len(terms) > maxSearchTerms {
+			terms = terms[:maxSearchTerms]
+		}
+
+		limit := defaultSearchLimit
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxSearchLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxSearchLimit)})
+				return
+			}
+			limit = n
+		}
+		isArchived, err := parseBoolQuery(c, "is_archived")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		searchType := c.Query("type")
+		if searchType != "" && searchType != "company" && searchType != "event" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type must be company or event"})
+			return
+		}
+
+		results := []searchResult{}
+
+		if searchType == "" || searchType == "company" {
+			var rows []companySearchRow
+			if err := searchQuery(db, "companies", companySearchFields, userID, q, terms, isArchived).Scan(&rows).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search companies"})
+				return
+			}
+			for _, row := range rows {
+				score, field, snippet := scoreFields(companySearchFields, map[string]string{
+					"name": row.Name, "industry": row.Industry, "position": row.Position, "notes": row.Notes,
+				}, terms)
+				subtitle := strings.TrimSpace(html.UnescapeString(row.Industry) + " " + html.UnescapeString(row.Position))
+				results = append(results, searchResult{
+					Type:         "company",
+					ID:           row.ID,
+					CompanyID:    row.ID,
+					Title:        html.UnescapeString(row.Name),
+					Subtitle:     subtitle,
+					IsArchived:   row.IsArchived,
+					MatchedField: field,
+					Snippet:      snippet,
+					Rank:         score + row.FtsRank*10,
+					UpdatedAt:    row.UpdatedAt,
+				})
+			}
+		}
+
+		if searchType == "" || searchType == "event" {
+			var rows []eventSearchRow
+			if err := searchQuery(db, "events", eventSearchFields, userID, q, terms, isArchived).Scan(&rows).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search events"})
+				return
+			}
+			for _, row := range rows {
+				score, field, snippet := scoreFields(eventSearchFields, map[string]string{
+					"title": row.Title, "location": row.Location, "notes": row.Notes,
+				}, terms)
+				results = append(results, searchResult{
+					Type:         "event",
+					ID:           row.ID,
+					CompanyID:    row.CompanyID,
+					Title:        html.UnescapeString(row.Title),
+					Subtitle:     html.UnescapeString(row.CompanyName),
+					IsArchived:   row.IsArchived,
+					MatchedField: field,
+					Snippet:      snippet,
+					Rank:         score + row.FtsRank*10,
+					UpdatedAt:    row.UpdatedAt,
+				})
+			}
+		}
+
+		sort.SliceStable(results, func(i, j int) bool {
+			if results[i].Rank != results[j].Rank {
+				return results[i].Rank > results[j].Rank
+			}
+			return results[i].UpdatedAt.After(results[j].UpdatedAt)
+		})
+		total := len(results)
+		if len(results) > limit {
+			results = results[:limit]
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"query":   q,
+			"total":   total,
+			"results": results,
+		})
+	}
+}
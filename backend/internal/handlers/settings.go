@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// loadUserSettings ユーザー設定を取得（未保存の場合はデフォルト値を返す）
+func loadUserSettings(db *gorm.DB, userID string) (models.UserSettings, error) {
+	var settings models.UserSettings
+	if err := db.Where("user_id = ?", userID).First(&settings).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.NewUserSettings(userID), nil
+		}
+		return settings, err
+	}
+	return settings, nil
+}
+
+func GetSettings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		settings, err := loadUserSettings(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+// parseClock "HH:MM" を0時からの経過分に変換
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validateSettings 構造体のバリデーションに加え、タイムゾーンと勤務時間の形式を検証
+func validateSettings(settings *models.UserSettings) error {
+	validate := validator.New()
+	if err := validate.Struct(settings); err != nil {
+		return err
+	}
+	// Go の tz データベースで解決できるタイムゾーン名のみ許可（"Local" や空文字は不可）
+	if _, err := time.LoadLocation(settings.Timezone); err != nil || settings.Timezone == "" || settings.Timezone == "Local" {
+		return errors.New("unknown timezone " + settings.Timezone)
+	}
+	start, err := parseClock(settings.WorkingHoursStart)
+	if err != nil {
+		return errors.New("working_hours_start must be in HH:MM format")
+	}
+	end, err := parseClock(settings.WorkingHoursEnd)
+	if err != nil {
+		return errors.New("working_hours_end must be in HH:MM format")
+	}
+	if start >= end {
+		return errors.New("working_hours_start must be before working_hours_end")
+	}
+	if settings.DigestEnabled && settings.DigestEmail == "" {
+		return errors.New("digest_email is required to enable the daily digest")
+	}
+	return nil
+}
+
+// postgresTimezoneExists PostgreSQL がタイムゾーン名を解決できるか
+// （自動アーカイブや集計の AT TIME ZONE で使うため、Go の tz データベースだけでなく PostgreSQL 側でも確認する）
+func postgresTimezoneExists(db *gorm.DB, name string) (bool, error) {
+	var count int64
+	if err := db.Raw("SELECT count(*) FROM pg_timezone_names WHERE name = ?", name).Scan(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func UpdateSettings(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		// 未保存の場合はデフォルト値で作成してから更新する
+		// （GORM は default タグ付きのゼロ値を INSERT しないため、false や 0 を確実に保存するため）
+		settings := models.NewUserSettings(userID)
+		if err := db.Where("user_id = ?", userID).Attrs(settings).FirstOrCreate(&settings).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+		before := settings
+
+		// 更新用のデータ構造
+		var updateData struct {
+			ConflictBufferMinutes        *int    `json:"conflict_buffer_minutes"`
+			Timezone                     *string `json:"timezone"`
+			DefaultInterviewDuration     *int    `json:"default_interview_duration"`
+			AutoArchiveRejectedCompanies *bool   `json:"auto_archive_rejected_companies"`
+			AutoArchiveEvents            *bool   `json:"auto_archive_events"`
+			AutoArchiveDelayDays         *int    `json:"auto_archive_delay_days"`
+			WorkingHoursStart            *string `json:"working_hours_start"`
+			WorkingHoursEnd              *string `json:"working_hours_end"`
+			DigestEnabled                *bool   `json:"digest_enabled"`
+			DigestEmail                  *string `json:"digest_email"`
+			DigestHour                   *int    `json:"digest_hour"`
+		}
+
+		if err := c.ShouldBindJSON(&updateData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// 部分更新の処理
+		if updateData.ConflictBufferMinutes != nil {
+			settings.ConflictBufferMinutes = *updateData.ConflictBufferMinutes
+		}
+		if updateData.Timezone != nil {
+			settings.Timezone = strings.TrimSpace(*updateData.Timezone)
+		}
+		if updateData.DefaultInterviewDuration != nil {
+			settings.DefaultInterviewDuration = *updateData.DefaultInterviewDuration
+		}
+		if updateData.AutoArchiveRejectedCompanies != nil {
+			settings.AutoArchiveRejectedCompanies = *updateData.AutoArchiveRejectedCompanies
+		}
+		if updateData.AutoArchiveEvents != nil {
+			settings.AutoArchiveEvents = *updateData.AutoArchiveEvents
+		}
+		if updateData.AutoArchiveDelayDays != nil {
+			settings.AutoArchiveDelayDays = *updateData.AutoArchiveDelayDays
+		}
+		if updateData.WorkingHoursStart != nil {
+			settings.WorkingHoursStart = strings.TrimSpace(*updateData.WorkingHoursStart)
+		}
+		if updateData.WorkingHoursEnd != nil {
+			settings.WorkingHoursEnd = strings.TrimSpace(*updateData.WorkingHoursEnd)
+		}
+		if updateData.DigestEnabled != nil {
+			settings.DigestEnabled = *updateData.DigestEnabled
+		}
+		if updateData.DigestEmail != nil {
+			settings.DigestEmail = strings.TrimSpace(*updateData.DigestEmail)
+		}
+		if updateData.DigestHour != nil {
+			settings.DigestHour = *updateData.DigestHour
+		}
+		// ダイジェストの宛先が未設定の場合はログイン中のメールアドレスを使う
+		if settings.DigestEnabled && settings.DigestEmail == "" {
+			settings.DigestEmail = c.GetString("user_email")
+		}
+
+		// バリデーション
+		if err := validateSettings(&settings); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+			return
+		}
+		if settings.Timezone != before.Timezone {
+			exists, err := postgresTimezoneExists(db, settings.Timezone)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate timezone"})
+				return
+			}
+			if !exists {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: unknown timezone " + settings.Timezone})
+				return
+			}
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&settings).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, auditActor(c), audit.EntitySettings, settings.UserID, audit.ActionUpdate, before, settings)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// stageRejected どのステージからでも遷移できる終了ステージ
+const stageRejected = "rejected"
+
+// stageOrder 選考ステージの前進方向の順序
+var stageOrder = []string{"entry", "document_review", "first_interview", "second_interview", "final_interview", "offer"}
+
+// StagePolicy 選考ステージの遷移ルール
+//   - 前進（AllowSkip が false の場合は次のステージのみ）
+//   - rejected へはどこからでも
+//   - rejected からは reopen を明示した場合のみ任意のステージへ
+type StagePolicy struct {
+	Order     []string
+	AllowSkip bool
+}
+
+// NewStagePolicy 標準の順序でポリシーを作る
+func NewStagePolicy(allowSkip bool) StagePolicy {
+	return StagePolicy{Order: stageOrder, AllowSkip: allowSkip}
+}
+
+func (p StagePolicy) index(stage string) int {
+	for i, s := range p.Order {
+		if s == stage {
+			return i
+		}
+	}
+	return -1
+}
+
+// NextStages from から遷移可能なステージ（rejected からの場合は reopen が必要）
+func (p StagePolicy) NextStages(from string) []string {
+	if from == stageRejected {
+		return append([]string{}, p.Order...)
+	}
+
+	next := []string{}
+	if i := p.index(from); i >= 0 {
+		for j := i + 1; j < len(p.Order); j++ {
+			next = append(next, p.Order[j])
+			if !p.AllowSkip {
+				break
+			}
+		}
+	}
+	return append(next, stageRejected)
+}
+
+// Allowed from から to への遷移が許可されるか（同じステージへの更新は常に許可）
+func (p StagePolicy) Allowed(from, to string, reopen bool) bool {
+	if from == to {
+		return true
+	}
+	if from == stageRejected && !reopen {
+		return false
+	}
+	for _, s := range p.NextStages(from) {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCompanyNextStages 現在のステージから選択可能なステージ
+func GetCompanyNextStages(db *gorm.DB, policy StagePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		companyID := c.Param("id")
+
+		var company models.Company
+		if err := db.Where("id = ? AND user_id = ?", companyID, userID).First(&company).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"current_stage":   company.CurrentStage,
+			"next_stages":     policy.NextStages(company.CurrentStage),
+			"requires_reopen": company.CurrentStage == stageRejected,
+		})
+	}
+}
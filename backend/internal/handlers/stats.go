@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// dateRange from / to クエリパラメータ（YYYY-MM-DD、to はその日の終わりまで含む）
+type dateRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// parseDateRange from / to を指定タイムゾーンの日付として解釈する
+func parseDateRange(c *gin.Context, loc *time.Location) (dateRange, error) {
+	var r dateRange
+	if v := c.Query("from"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			return r, err
+		}
+		r.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			return r, err
+		}
+		end := t.AddDate(0, 0, 1)
+		r.To = &end
+	}
+	return r, nil
+}
+
+// funnelStage ステージごとの集計
+type funnelStage struct {
+	Stage          string   `json:"stage"`
+	Reached        int      `json:"reached"`
+	Current        int      `json:"current"`
+	ConversionRate *float64 `json:"conversion_rate"` // 前のステージに到達した企業のうち、このステージに到達した割合
+	MedianDays     *float64 `json:"median_days"`     // このステージに滞在した日数の中央値（次のステージへ進んだもののみ）
+}
+
+// industryFunnel 業界ごとの集計
+type industryFunnel struct {
+	Industry string         `json:"industry"`
+	Total    int            `json:"total"`
+	Reached  map[string]int `json:"reached"`
+	Rejected int            `json:"rejected"`
+}
+
+// furthestStageIndex 企業が到達した最も先のステージ（stageOrder のインデックス）
+// 履歴がある場合は履歴を、なければ現在のステージを使う
+func furthestStageIndex(company models.Company, transitions []models.CompanyStageTransition) int {
+	furthest := 0
+	consider := func(stage string) {
+		for i, s := range stageOrder {
+			if s == stage && i > furthest {
+				furthest = i
+			}
+		}
+	}
+	consider(company.CurrentStage)
+	for _, t := range transitions {
+		consider(t.FromStage)
+		consider(t.ToStage)
+	}
+	return furthest
+}
+
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Float64s(values)
+	m := values[len(values)/2]
+	if len(values)%2 == 0 {
+		m = (values[len(values)/2-1] + values[len(values)/2]) / 2
+	}
+	m = math.Round(m*10) / 10
+	return &m
+}
+
+func ratio(numerator, denominator int) *float64 {
+	if denominator == 0 {
+		return nil
+	}
+	r := math.Round(float64(numerator)/float64(denominator)*1000) / 1000
+	return &r
+}
+
+// GetFunnelStats 選考ファネルの集計
+// クエリ: from / to（企業の登録日で絞り込み）、include_archived（既定 true）
+func GetFunnelStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		settings, err := loadUserSettings(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+		dates, err := parseDateRange(c, settings.Location())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format, expected YYYY-MM-DD"})
+			return
+		}
+
+		query := db.Select("id, industry, current_stage, created_at").Where("user_id = ?", userID)
+		if c.DefaultQuery("include_archived", "true") != "true" {
+			query = query.Where("is_archived = ?", false)
+		}
+		if dates.From != nil {
+			query = query.Where("created_at >= ?", *dates.From)
+		}
+		if dates.To != nil {
+			query = query.Where("created_at < ?", *dates.To)
+		}
+
+		var companies []models.Company
+		if err := query.Find(&companies).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch companies"})
+			return
+		}
+
+		companyIDs := make([]string, 0, len(companies))
+		for _, company := range companies {
+			companyIDs = append(companyIDs, company.ID)
+		}
+		var transitions []models.CompanyStageTransition
+		if len(companyIDs) > 0 {
+			if err := db.Where("user_id = ? AND company_id IN ?", userID, companyIDs).
+				Order("transitioned_at ASC").
+				Find(&transitions).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stage history"})
+				return
+			}
+		}
+		transitionsByCompany := map[string][]models.CompanyStageTransition{}
+		for _, t := range transitions {
+			transitionsByCompany[t.CompanyID] = append(transitionsByCompany[t.CompanyID], t)
+		}
+
+		reached := make([]int, len(stageOrder))
+		current := map[string]int{}
+		daysInStage := map[string][]float64{}
+		industries := map[string]*industryFunnel{}
+		rejected := 0
+
+		for _, company := range companies {
+			history := transitionsByCompany[company.ID]
+			furthest := furthestStageIndex(company, history)
+			for i := 0; i <= furthest; i++ {
+				reached[i]++
+			}
+			current[company.CurrentStage]++
+			if company.CurrentStage == stageRejected {
+				rejected++
+			}
+
+			// 滞在日数: あるステージに入ってから次の遷移までの期間
+			for i := 0; i+1 < len(history); i++ {
+				days := history[i+1].TransitionedAt.Sub(history[i].TransitionedAt).Hours() / 24
+				daysInStage[history[i].ToStage] = append(daysInStage[history[i].ToStage], days)
+			}
+
+			industry := company.Industry
+			if industry == "" {
+				industry = "未設定"
+			}
+			funnel, ok := industries[industry]
+			if !ok {
+				funnel = &industryFunnel{Industry: industry, Reached: map[string]int{}}
+				industries[industry] = funnel
+			}
+			funnel.Total++
+			for i := 0; i <= furthest; i++ {
+				funnel.Reached[stageOrder[i]]++
+			}
+			if company.CurrentStage == stageRejected {
+				funnel.Rejected++
+			}
+		}
+
+		stages := make([]funnelStage, 0, len(stageOrder))
+		for i, stage := range stageOrder {
+			s := funnelStage{
+				Stage:      stage,
+				Reached:    reached[i],
+				Current:    current[stage],
+				MedianDays: median(daysInStage[stage]),
+			}
+			if i > 0 {
+				s.ConversionRate = ratio(reached[i], reached[i-1])
+			}
+			stages = append(stages, s)
+		}
+
+		industryList := make([]industryFunnel, 0, len(industries))
+		for _, funnel := range industries {
+			industryList = append(industryList, *funnel)
+		}
+		sort.Slice(industryList, func(i, j int) bool {
+			if industryList[i].Total != industryList[j].Total {
+				return industryList[i].Total > industryList[j].Total
+			}
+			return industryList[i].Industry < industryList[j].Industry
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"total_companies": len(companies),
+			"rejected":        rejected,
+			"offer_rate":      ratio(reached[len(stageOrder)-1], len(companies)),
+			"stages":          stages,
+			"industries":      industryList,
+		})
+	}
+}
+
+type weeklyTypeRow struct {
+	Week      string
+	WeekStart time.Time
+	Type      string
+	Count     int
+	Minutes   int
+}
+
+type typeRow struct {
+	Type    string `json:"type"`
+	Count   int    `json:"count"`
+	Minutes int    `json:"minutes"`
+}
+
+type busyDayRow struct {
+	Day     string `json:"day"`
+	Count   int    `json:"count"`
+	Minutes int    `json:"minutes"`
+}
+
+// weeklyEventStats ISO週ごとの集計
+type weeklyEventStats struct {
+	Week      string         `json:"week"`
+	WeekStart string         `json:"week_start"`
+	Count     int            `json:"count"`
+	Minutes   int            `json:"minutes"`
+	ByType    map[string]int `json:"by_type"`
+}
+
+// GetEventStats 確定済み予定の集計（週別・種別・混雑日）をSQLで計算
+// クエリ: from / to（確定日時の開始で絞り込み）、include_archived（既定 true）
+func GetEventStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		settings, err := loadUserSettings(db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch settings"})
+			return
+		}
+		// 週・日の区切りはユーザーのタイムゾーンで判定
+		loc := settings.Location()
+		timezone := loc.String()
+
+		dates, err := parseDateRange(c, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format, expected YYYY-MM-DD"})
+			return
+		}
+		includeArchived := c.DefaultQuery("include_archived", "true") == "true"
+
+		confirmed := func() *gorm.DB {
+			query := db.Model(&models.Event{}).
+				Where("user_id = ? AND status = ? AND confirmed_slot IS NOT NULL AND (confirmed_slot->>'start_time') IS NOT NULL", userID, "confirmed")
+			if !includeArchived {
+				query = query.Where("is_archived = ?", false)
+			}
+			if dates.From != nil {
+				query = query.Where(models.ConfirmedStartExpr+" >= ?", *dates.From)
+			}
+			if dates.To != nil {
+				query = query.Where(models.ConfirmedStartExpr+" < ?", *dates.To)
+			}
+			return query
+		}
+
+		// 週・種別ごと
+		localStart := "(" + models.ConfirmedStartExpr + " AT TIME ZONE ?)"
+		var weeklyRows []weeklyTypeRow
+		if err := confirmed().
+			Select("to_char(date_trunc('week', "+localStart+"), 'IYYY-\"W\"IW') AS week, "+
+				"date_trunc('week', "+localStart+") AS week_start, "+
+				"type, COUNT(*) AS count, COALESCE(SUM(interview_duration), 0) AS minutes", timezone, timezone).
+			Group("week, week_start, type").
+			Order("week_start ASC, type ASC").
+			Scan(&weeklyRows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate events"})
+			return
+		}
+
+		// 種別ごと
+		var typeRows []typeRow
+		if err := confirmed().
+			Select("type, COUNT(*) AS count, COALESCE(SUM(interview_duration), 0) AS minutes").
+			Group("type").
+			Order("count DESC, type ASC").
+			Scan(&typeRows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate events"})
+			return
+		}
+
+		// 予定の多い日（上位5日）
+		var busyDays []busyDayRow
+		if err := confirmed().
+			Select("to_char("+localStart+", 'YYYY-MM-DD') AS day, COUNT(*) AS count, COALESCE(SUM(interview_duration), 0) AS minutes", timezone).
+			Group("day").
+			Order("count DESC, minutes DESC, day ASC").
+			Limit(5).
+			Scan(&busyDays).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate events"})
+			return
+		}
+
+		// 確定待ちの候補
+		var pendingCandidates int64
+		if err := db.Model(&models.Event{}).
+			Where("user_id = ? AND status = ? AND is_archived = ?", userID, "candidate", false).
+			Count(&pendingCandidates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to aggregate events"})
+			return
+		}
+
+		weeks := []weeklyEventStats{}
+		for _, row := range weeklyRows {
+			if len(weeks) == 0 || weeks[len(weeks)-1].Week != row.Week {
+				weeks = append(weeks, weeklyEventStats{
+					Week:      row.Week,
+					WeekStart: row.WeekStart.Format("2006-01-02"),
+					ByType:    map[string]int{},
+				})
+			}
+			w := &weeks[len(weeks)-1]
+			w.Count += row.Count
+			w.Minutes += row.Minutes
+			w.ByType[row.Type] += row.Count
+		}
+
+		totalEvents, totalMinutes := 0, 0
+		for _, row := range typeRows {
+			totalEvents += row.Count
+			totalMinutes += row.Minutes
+		}
+		if typeRows == nil {
+			typeRows = []typeRow{}
+		}
+		if busyDays == nil {
+			busyDays = []busyDayRow{}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"timezone":           timezone,
+			"total_events":       totalEvents,
+			"total_minutes":      totalMinutes,
+			"pending_candidates": pendingCandidates,
+			"by_type":            typeRows,
+			"weeks":              weeks,
+			"busiest_days":       busyDays,
+		})
+	}
+}
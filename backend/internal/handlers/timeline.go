@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"career-schedule-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// timelineItem タイムラインの1項目（kind に応じて stage_transition か event のどちらかが入る）
+type timelineItem struct {
+	Kind            string                         `json:"kind"`
+	At              time.Time                      `json:"at"`
+	StageTransition *models.CompanyStageTransition `json:"stage_transition,omitempty"`
+	Event           *models.Event                  `json:"event,omitempty"`
+}
+
+// eventTimelineTime 予定をタイムラインに置く時刻（確定日時があればその開始、なければ登録日時）
+func eventTimelineTime(event models.Event) time.Time {
+	if len(event.ConfirmedSlot) > 0 {
+		var confirmed timeSlot
+		if err := json.Unmarshal(event.ConfirmedSlot, &confirmed); err == nil && confirmed.valid() {
+			return confirmed.StartTime
+		}
+	}
+	return event.CreatedAt
+}
+
+// GetCompanyTimeline ステージ履歴と予定を時系列にまとめて返す
+func GetCompanyTimeline(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		companyID := c.Param("id")
+
+		var company models.Company
+		if err := db.Where("id = ? AND user_id = ?", companyID, userID).First(&company).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+			return
+		}
+
+		var transitions []models.CompanyStageTransition
+		if err := db.Where("company_id = ? AND user_id = ?", companyID, userID).
+			Order("transitioned_at ASC").
+			Find(&transitions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stage history"})
+			return
+		}
+
+		var events []models.Event
+		if err := db.Where("company_id = ? AND user_id = ?", companyID, userID).Find(&events).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+			return
+		}
+
+		items := make([]timelineItem, 0, len(transitions)+len(events))
+		for i := range transitions {
+			items = append(items, timelineItem{
+				Kind:            "stage_transition",
+				At:              transitions[i].TransitionedAt,
+				StageTransition: &transitions[i],
+			})
+		}
+		for i := range events {
+			items = append(items, timelineItem{
+				Kind:  "event",
+				At:    eventTimelineTime(events[i]),
+				Event: &events[i],
+			})
+		}
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].At.Before(items[j].At)
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"company":  company,
+			"timeline": items,
+		})
+	}
+}
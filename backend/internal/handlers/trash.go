@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errCompanyInTrash 企業がゴミ箱にある予定は、先に企業を復元する必要がある
+var errCompanyInTrash = errors.New("company is in trash")
+
+// ゴミ箱のURLで指定する種別
+const (
+	trashTypeCompanies = "companies"
+	trashTypeEvents    = "events"
+)
+
+// GetTrash ゴミ箱にある企業・予定（削除日時の新しい順）
+// 各項目は deleted_at から retention_days 日後に完全に削除される
+func GetTrash(db *gorm.DB, retentionDays int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var companies []models.Company
+		if err := db.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Order("deleted_at DESC").Find(&companies).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+			return
+		}
+		var events []models.Event
+		if err := db.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID).Order("deleted_at DESC").Find(&events).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"companies":      companies,
+			"events":         events,
+			"retention_days": retentionDays,
+		})
+	}
+}
+
+// RestoreTrash ゴミ箱の企業・予定を元に戻す（:type は companies / events）
+// 企業を戻すと、企業と一緒にゴミ箱へ移動した予定も戻る
+func RestoreTrash(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		itemType := c.Param("type")
+		itemID := c.Param("id")
+
+		var restored interface{}
+		var err error
+		switch itemType {
+		case trashTypeCompanies:
+			restored, err = restoreCompany(db, auditActor(c), userID, itemID)
+		case trashTypeEvents:
+			restored, err = restoreEvent(db, auditActor(c), userID, itemID)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type must be companies or events"})
+			return
+		}
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Item not found in trash"})
+				return
+			}
+			if err == errCompanyInTrash {
+				c.JSON(http.StatusConflict, gin.H{"error": "The event's company is in trash; restore the company first"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore item"})
+			return
+		}
+
+		c.JSON(http.StatusOK, restored)
+	}
+}
+
+func restoreCompany(db *gorm.DB, actor audit.Actor, userID, companyID string) (models.Company, error) {
+	var company models.Company
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", companyID, userID).First(&company).Error; err != nil {
+			return err
+		}
+		before := company
+		company.DeletedAt = gorm.DeletedAt{}
+		company.UpdatedAt = time.Now()
+		if err := tx.Unscoped().Model(&company).Select("deleted_at", "updated_at").Updates(&company).Error; err != nil {
+			return err
+		}
+		if _, err := restoreCompanyEvents(tx, actor, company, before.DeletedAt.Time); err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actor, audit.EntityCompany, company.ID, audit.ActionRestore, before, company); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, userID, webhooks.CompanyRestored, company)
+	})
+	return company, err
+}
+
+func restoreEvent(db *gorm.DB, actor audit.Actor, userID, eventID string) (models.Event, error) {
+	var event models.Event
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", eventID, userID).First(&event).Error; err != nil {
+			return err
+		}
+		var trashedCompanies int64
+		if err := tx.Unscoped().Model(&models.Company{}).
+			Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", event.CompanyID, userID).
+			Count(&trashedCompanies).Error; err != nil {
+			return err
+		}
+		if trashedCompanies > 0 {
+			return errCompanyInTrash
+		}
+		before := event
+		event.DeletedAt = gorm.DeletedAt{}
+		event.UpdatedAt = time.Now()
+		if err := tx.Unscoped().Model(&event).Select("deleted_at", "updated_at").Updates(&event).Error; err != nil {
+			return err
+		}
+		if err := audit.Record(tx, actor, audit.EntityEvent, event.ID, audit.ActionRestore, before, event); err != nil {
+			return err
+		}
+		return webhooks.Enqueue(tx, userID, webhooks.EventRestored, event)
+	})
+	return event, err
+}
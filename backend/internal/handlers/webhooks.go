@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/middleware"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxWebhooksPerUser ユーザーごとに登録できる Webhook の数
+const maxWebhooksPerUser = 10
+
+// validateWebhook 構造体のバリデーションに加え、URL のスキームと購読する種別を検証
+func validateWebhook(hook *models.Webhook) error {
+	validate := validator.New()
+	if err := validate.Struct(hook); err != nil {
+		return err
+	}
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("url must be an http or https URL")
+	}
+	for _, kind := range hook.Events {
+		if !webhooks.ValidKind(kind) {
+			return errors.New("unknown event kind " + kind + " (available: " + strings.Join(webhooks.Kinds, ", ") + ")")
+		}
+	}
+	return nil
+}
+
+// dedupeKinds 購読する種別の重複を除く
+func dedupeKinds(kinds []string) datatypes.JSONSlice[string] {
+	seen := map[string]bool{}
+	result := datatypes.JSONSlice[string]{}
+	for _, kind := range kinds {
+		kind = strings.TrimSpace(kind)
+		if kind == "" || seen[kind] {
+			continue
+		}
+		seen[kind] = true
+		result = append(result, kind)
+	}
+	return result
+}
+
+// findWebhook ユーザーの Webhook を取得（見つからなければ 404 を返して false）
+func findWebhook(c *gin.Context, db *gorm.DB, userID, webhookID string) (models.Webhook, bool) {
+	var hook models.Webhook
+	if err := db.Where("id = ? AND user_id = ?", webhookID, userID).First(&hook).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return hook, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook"})
+		return hook, false
+	}
+	return hook, true
+}
+
+func GetWebhooks(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var hooks []models.Webhook
+		if err := db.Where("user_id = ?", userID).Order("created_at ASC").Find(&hooks).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"webhooks":    hooks,
+			"event_kinds": webhooks.Kinds,
+		})
+	}
+}
+
+// CreateWebhook Webhook の登録。署名用のシークレットはこのレスポンスでのみ返す
+func CreateWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		var request struct {
+			URL         string   `json:"url"`
+			Events      []string `json:"events"`
+			Description string   `json:"description"`
+			IsActive    *bool    `json:"is_active"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		hook := models.Webhook{
+			UserID:      userID,
+			URL:         strings.TrimSpace(request.URL),
+			Events:      dedupeKinds(request.Events),
+			Description: strings.TrimSpace(request.Description),
+			IsActive:    true,
+		}
+		if request.IsActive != nil {
+			hook.IsActive = *request.IsActive
+		}
+		if err := validateWebhook(&hook); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+			return
+		}
+
+		var count int64
+		if err := db.Model(&models.Webhook{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count webhooks"})
+			return
+		}
+		if count >= maxWebhooksPerUser {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many webhooks (max " + strconv.Itoa(maxWebhooksPerUser) + ")"})
+			return
+		}
+
+		secret, err := generateToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+			return
+		}
+		hook.Secret = secret
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&hook).Error; err != nil {
+				return err
+			}
+			// is_active=false を指定した場合（GORM は default タグ付きのゼロ値を INSERT しないため）
+			if !hook.IsActive {
+				if err := tx.Model(&hook).Update("is_active", false).Error; err != nil {
+					return err
+				}
+			}
+			return audit.Record(tx, auditActor(c), audit.EntityWebhook, hook.ID, audit.ActionCreate, nil, hook)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"webhook": hook,
+			"secret":  secret,
+		})
+	}
+}
+
+// UpdateWebhook 部分更新。rotate_secret=true でシークレットを再発行してレスポンスで返す
+// シークレットの再発行はログインセッション（JWT）からのみ（個人用アクセストークンでは 403）
+func UpdateWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		hook, ok := findWebhook(c, db, userID, c.Param("id"))
+		if !ok {
+			return
+		}
+		before := hook
+
+		var updateData struct {
+			URL          *string   `json:"url"`
+			Events       *[]string `json:"events"`
+			Description  *string   `json:"description"`
+			IsActive     *bool     `json:"is_active"`
+			RotateSecret bool      `json:"rotate_secret"`
+		}
+		if err := c.ShouldBindJSON(&updateData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if updateData.RotateSecret && c.GetString("auth_method") != middleware.AuthMethodSession {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Rotating the secret requires a signed-in session"})
+			return
+		}
+
+		if updateData.URL != nil {
+			hook.URL = strings.TrimSpace(*updateData.URL)
+		}
+		if updateData.Events != nil {
+			hook.Events = dedupeKinds(*updateData.Events)
+		}
+		if updateData.Description != nil {
+			hook.Description = strings.TrimSpace(*updateData.Description)
+		}
+		if updateData.IsActive != nil {
+			hook.IsActive = *updateData.IsActive
+		}
+		if err := validateWebhook(&hook); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed: " + err.Error()})
+			return
+		}
+
+		var secret string
+		if updateData.RotateSecret {
+			var err error
+			if secret, err = generateToken(); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret"})
+				return
+			}
+			hook.Secret = secret
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&hook).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, auditActor(c), audit.EntityWebhook, hook.ID, audit.ActionUpdate, before, hook)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+			return
+		}
+
+		if secret != "" {
+			c.JSON(http.StatusOK, gin.H{"webhook": hook, "secret": secret})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"webhook": hook})
+	}
+}
+
+// DeleteWebhook Webhook と送信履歴を削除（送信待ちも破棄される）
+func DeleteWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+		webhookID := c.Param("id")
+
+		var rowsAffected int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var hook models.Webhook
+			result := tx.Clauses(clause.Returning{}).Where("id = ? AND user_id = ?", webhookID, userID).Delete(&hook)
+			if result.Error != nil {
+				return result.Error
+			}
+			rowsAffected = result.RowsAffected
+			if rowsAffected == 0 {
+				return nil
+			}
+			if err := tx.Where("webhook_id = ? AND user_id = ?", webhookID, userID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+				return err
+			}
+			return audit.Record(tx, auditActor(c), audit.EntityWebhook, webhookID, audit.ActionDelete, hook, nil)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+			return
+		}
+
+		if rowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+	}
+}
+
+// GetWebhookDeliveries 送信履歴（新しい順）
+// クエリ: status（pending / delivered / failed）、limit（既定50、最大200）
+func GetWebhookDeliveries(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		hook, ok := findWebhook(c, db, userID, c.Param("id"))
+		if !ok {
+			return
+		}
+
+		limit := defaultPageLimit
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxPageLimit {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxPageLimit)})
+				return
+			}
+			limit = n
+		}
+
+		query := db.Where("webhook_id = ? AND user_id = ?", hook.ID, userID)
+		switch status := c.Query("status"); status {
+		case "":
+		case models.WebhookDeliveryPending, models.WebhookDeliverySending, models.WebhookDeliveryDelivered, models.WebhookDeliveryFailed:
+			query = query.Where("status = ?", status)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, sending, delivered or failed"})
+			return
+		}
+
+		var deliveries []models.WebhookDelivery
+		if err := query.Order("created_at DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook deliveries"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+	}
+}
+
+// RedeliverWebhook 送信履歴の1件を再送待ちに戻す（次回のジョブ実行で送信）
+func RedeliverWebhook(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if db == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+			return
+		}
+		userID := c.GetString("user_id")
+
+		hook, ok := findWebhook(c, db, userID, c.Param("id"))
+		if !ok {
+			return
+		}
+
+		result := db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND webhook_id = ? AND user_id = ?", c.Param("delivery_id"), hook.ID, userID).
+			Updates(map[string]interface{}{
+				"status":          models.WebhookDeliveryPending,
+				"attempts":        0,
+				"next_attempt_at": time.Now(),
+			})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver webhook"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook delivery not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook delivery queued"})
+	}
+}
@@ -0,0 +1,339 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoCalendar VCALENDAR が見つからない場合のエラー
+var ErrNoCalendar = errors.New("ical: no VCALENDAR component found")
+
+// maxDecodeLines 不正に巨大な入力を打ち切るための上限
+const maxDecodeLines = 100000
+
+// property パラメータ付きのプロパティ1行
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode iCalendar 形式を読み込む
+// 日時が浮動時刻（Z も TZID もない）の場合は defaultLoc として解釈する
+// VEVENT の日時・期間が読み込めない場合はファイル全体をエラーにせず、その予定の Err に記録する
+func Decode(r io.Reader, defaultLoc *time.Location) (*Calendar, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cal       *Calendar
+		current   *Event
+		hasEnd    bool
+		duration  time.Duration
+		nestLevel int // VEVENT 内の VALARM などは読み飛ばす
+	)
+
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("ical: line %d: %w", i+1, err)
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			switch {
+			case strings.EqualFold(prop.value, "VCALENDAR") && cal == nil:
+				cal = &Calendar{}
+			case strings.EqualFold(prop.value, "VEVENT") && cal != nil && current == nil:
+				current = &Event{}
+				hasEnd = false
+				duration = 0
+			default:
+				nestLevel++
+			}
+			continue
+		case "END":
+			switch {
+			case nestLevel > 0:
+				nestLevel--
+			case strings.EqualFold(prop.value, "VEVENT") && current != nil:
+				if !hasEnd && duration > 0 {
+					current.End = current.Start.Add(duration)
+				}
+				cal.Events = append(cal.Events, *current)
+				current = nil
+			case strings.EqualFold(prop.value, "VCALENDAR") && cal != nil:
+				return cal, nil
+			}
+			continue
+		}
+
+		if cal == nil || nestLevel > 0 {
+			continue
+		}
+
+		if current == nil {
+			switch prop.name {
+			case "X-WR-CALNAME":
+				cal.Name = unescapeText(prop.value)
+			case "X-WR-TIMEZONE":
+				cal.Timezone = prop.value
+			}
+			continue
+		}
+
+		switch prop.name {
+		case "UID":
+			current.UID = unescapeText(prop.value)
+		case "SUMMARY":
+			current.Summary = unescapeText(prop.value)
+		case "LOCATION":
+			current.Location = unescapeText(prop.value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(prop.value)
+		case "STATUS":
+			current.Status = strings.ToUpper(prop.value)
+		case "SEQUENCE":
+			current.Sequence, _ = strconv.Atoi(prop.value)
+		case "DTSTART":
+			t, allDay, err := parseDateTime(prop, defaultLoc)
+			if err != nil {
+				current.fail(i+1, err)
+				continue
+			}
+			current.Start = t
+			if allDay && !hasEnd && duration == 0 {
+				duration = 24 * time.Hour
+			}
+		case "DTEND":
+			t, _, err := parseDateTime(prop, defaultLoc)
+			if err != nil {
+				current.fail(i+1, err)
+				continue
+			}
+			current.End = t
+			hasEnd = true
+		case "DURATION":
+			d, err := parseDuration(prop.value)
+			if err != nil {
+				current.fail(i+1, err)
+				continue
+			}
+			duration = d
+		}
+	}
+
+	if cal == nil {
+		return nil, ErrNoCalendar
+	}
+	return nil, errors.New("ical: unexpected end of input")
+}
+
+// fail 予定の最初の読み込みエラーを行番号付きで記録する
+func (e *Event) fail(line int, err error) {
+	if e.Err == nil {
+		e.Err = fmt.Errorf("line %d: %w", line, err)
+	}
+}
+
+// unfoldLines 折り返された行を結合する（RFC 5545 3.1）
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if len(lines) >= maxDecodeLines {
+			return nil, errors.New("ical: too many lines")
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// parseProperty "NAME;PARAM=VALUE:value" 形式を分解する
+func parseProperty(line string) (property, error) {
+	prop := property{params: map[string]string{}}
+
+	// パラメータ値はダブルクォートで囲まれている場合があり、その中の ':' と ';' は区切りではない
+	inQuote := false
+	nameEnd := -1
+	valueStart := -1
+	for i, r := range line {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ';' && !inQuote && nameEnd < 0:
+			nameEnd = i
+		case r == ':' && !inQuote:
+			valueStart = i
+		}
+		if valueStart >= 0 {
+			break
+		}
+	}
+	if valueStart < 0 {
+		return prop, fmt.Errorf("missing ':' in %q", line)
+	}
+	if nameEnd < 0 {
+		nameEnd = valueStart
+	}
+
+	prop.name = strings.ToUpper(line[:nameEnd])
+	prop.value = line[valueStart+1:]
+	if nameEnd < valueStart {
+		for _, param := range splitParams(line[nameEnd+1 : valueStart]) {
+			key, value, ok := strings.Cut(param, "=")
+			if !ok {
+				continue
+			}
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, nil
+}
+
+func splitParams(s string) []string {
+	var params []string
+	inQuote := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ';' && !inQuote:
+			params = append(params, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(params, s[start:])
+}
+
+// parseDateTime DATE-TIME / DATE 値を解釈する。2番目の戻り値は終日（DATE）かどうか
+func parseDateTime(prop property, defaultLoc *time.Location) (time.Time, bool, error) {
+	loc := defaultLoc
+	if loc == nil {
+		loc = time.UTC
+	}
+	if tzid := prop.params["TZID"]; tzid != "" {
+		l, err := loadTZID(tzid)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		loc = l
+	}
+
+	value := prop.value
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcDateTimeFormat, value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, false, nil
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, false, nil
+}
+
+// parseDuration "P1DT2H30M" 形式の期間を解釈する（RFC 5545 3.3.6）
+func parseDuration(s string) (time.Duration, error) {
+	value := strings.TrimPrefix(strings.TrimPrefix(s, "+"), "-")
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	value = value[1:]
+
+	var total time.Duration
+	inTime := false
+	num := ""
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			num += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		num = ""
+		switch {
+		case r == 'W' && !inTime:
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			total += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if strings.HasPrefix(s, "-") {
+		total = -total
+	}
+	return total, nil
+}
+
+// unescapeText TEXT 型の値のエスケープを戻す
+func unescapeText(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if escaped {
+			switch r {
+			case 'n', 'N':
+				b.WriteRune('\n')
+			default:
+				b.WriteRune(r)
+			}
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
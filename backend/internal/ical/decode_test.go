@@ -0,0 +1,74 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeEvents(t *testing.T, events ...string) []Event {
+	t.Helper()
+	body := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" + strings.Join(events, "") + "END:VCALENDAR\r\n"
+	cal, err := Decode(strings.NewReader(body), time.UTC)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return cal.Events
+}
+
+func vevent(uid string, props ...string) string {
+	return "BEGIN:VEVENT\r\nUID:" + uid + "\r\n" + strings.Join(props, "\r\n") + "\r\nEND:VEVENT\r\n"
+}
+
+// 読み込めない日時・期間・TZID はその予定だけのエラーになり、他の予定は読み込む
+func TestDecodeRecordsErrorsPerEvent(t *testing.T) {
+	events := decodeEvents(t,
+		vevent("bad-start", "DTSTART:2026-01-01", "DTEND:20260101T100000Z"),
+		vevent("bad-duration", "DTSTART:20260101T090000Z", "DURATION:PT1X"),
+		vevent("unknown-tzid", "DTSTART;TZID=Nowhere Standard Time:20260101T090000", "DTEND;TZID=Nowhere Standard Time:20260101T100000"),
+		vevent("ok", "DTSTART:20260101T090000Z", "DTEND:20260101T100000Z"),
+	)
+
+	tests := []struct {
+		uid  string
+		want string
+	}{
+		{"bad-start", `invalid date-time "2026-01-01"`},
+		{"bad-duration", `invalid duration "PT1X"`},
+		{"unknown-tzid", `unknown TZID "Nowhere Standard Time"`},
+		{"ok", ""},
+	}
+	if len(events) != len(tests) {
+		t.Fatalf("events = %d, want %d", len(events), len(tests))
+	}
+	for i, tt := range tests {
+		e := events[i]
+		if e.UID != tt.uid {
+			t.Fatalf("events[%d].UID = %q, want %q", i, e.UID, tt.uid)
+		}
+		if tt.want == "" {
+			if e.Err != nil {
+				t.Errorf("%s: Err = %v, want nil", tt.uid, e.Err)
+			}
+			continue
+		}
+		if e.Err == nil || !strings.Contains(e.Err.Error(), tt.want) {
+			t.Errorf("%s: Err = %v, want %q", tt.uid, e.Err, tt.want)
+		}
+	}
+}
+
+func TestDecodeTZID(t *testing.T) {
+	want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tzid := range []string{"Asia/Tokyo", "Tokyo Standard Time"} {
+		t.Run(tzid, func(t *testing.T) {
+			events := decodeEvents(t, vevent("x", "DTSTART;TZID=\""+tzid+"\":20260101T090000", "DURATION:PT1H"))
+			if len(events) != 1 || events[0].Err != nil {
+				t.Fatalf("events = %+v, want one without error", events)
+			}
+			if !events[0].Start.Equal(want) || !events[0].End.Equal(want.Add(time.Hour)) {
+				t.Errorf("slot = %v - %v, want %v - %v", events[0].Start, events[0].End, want, want.Add(time.Hour))
+			}
+		})
+	}
+}
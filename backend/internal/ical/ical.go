@@ -0,0 +1,138 @@
+// Package ical は RFC 5545 (iCalendar) 形式の読み書きを扱う
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	productID = "-//career-schedule-manager//career-schedule-api//JA"
+
+	// 1行あたりの最大オクテット数（RFC 5545 3.1）
+	maxLineOctets = 75
+
+	utcDateTimeFormat = "20060102T150405Z"
+)
+
+// Calendar VCALENDAR に相当
+type Calendar struct {
+	Name     string
+	Timezone string
+	Events   []Event
+}
+
+// Event VEVENT に相当
+type Event struct {
+	UID          string
+	Sequence     int
+	Summary      string
+	Location     string
+	Description  string
+	Status       string
+	Start        time.Time
+	End          time.Time
+	Created      time.Time
+	LastModified time.Time
+	// Err 日時などを読み込めなかった場合の最初のエラー（Decode のみ設定する）
+	Err error
+}
+
+// Encode カレンダーを iCalendar 形式で w に書き出す
+func (c *Calendar) Encode(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	lw := &lineWriter{w: bw}
+
+	lw.line("BEGIN", "VCALENDAR")
+	lw.line("VERSION", "2.0")
+	lw.line("PRODID", productID)
+	lw.line("CALSCALE", "GREGORIAN")
+	lw.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME", escapeText(c.Name))
+	}
+	if c.Timezone != "" {
+		lw.line("X-WR-TIMEZONE", c.Timezone)
+	}
+
+	stamp := time.Now()
+	for _, e := range c.Events {
+		lw.line("BEGIN", "VEVENT")
+		lw.line("UID", escapeText(e.UID))
+		lw.line("DTSTAMP", formatUTC(stamp))
+		lw.line("DTSTART", formatUTC(e.Start))
+		lw.line("DTEND", formatUTC(e.End))
+		lw.line("SEQUENCE", strconv.Itoa(e.Sequence))
+		lw.line("SUMMARY", escapeText(e.Summary))
+		if e.Location != "" {
+			lw.line("LOCATION", escapeText(e.Location))
+		}
+		if e.Description != "" {
+			lw.line("DESCRIPTION", escapeText(e.Description))
+		}
+		if e.Status != "" {
+			lw.line("STATUS", e.Status)
+		}
+		if !e.Created.IsZero() {
+			lw.line("CREATED", formatUTC(e.Created))
+		}
+		if !e.LastModified.IsZero() {
+			lw.line("LAST-MODIFIED", formatUTC(e.LastModified))
+		}
+		lw.line("END", "VEVENT")
+	}
+
+	lw.line("END", "VCALENDAR")
+	if lw.err != nil {
+		return lw.err
+	}
+	return bw.Flush()
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format(utcDateTimeFormat)
+}
+
+// escapeText TEXT 型の値をエスケープ（RFC 5545 3.3.11）
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(s)
+}
+
+// lineWriter CRLF 区切りと75オクテットでの折り返しを行う
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (lw *lineWriter) line(name, value string) {
+	if lw.err != nil {
+		return
+	}
+	content := name + ":" + value
+
+	// マルチバイト文字の途中で折り返さないようにする
+	var b strings.Builder
+	lineLen := 0
+	for _, r := range content {
+		size := len(string(r))
+		if lineLen+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			lineLen = 1
+		}
+		b.WriteRune(r)
+		lineLen += size
+	}
+	b.WriteString("\r\n")
+
+	_, lw.err = lw.w.WriteString(b.String())
+}
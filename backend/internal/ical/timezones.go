@@ -0,0 +1,59 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+)
+
+// windowsZones Outlook などが TZID に使う Windows のタイムゾーン名と IANA 名の対応
+// （CLDR windowsZones.xml の代表地域から主なものを抜粋）
+var windowsZones = map[string]string{
+	"Dateline Standard Time":         "Etc/GMT+12",
+	"UTC-11":                         "Etc/GMT+11",
+	"Hawaiian Standard Time":         "Pacific/Honolulu",
+	"Alaskan Standard Time":          "America/Anchorage",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"US Mountain Standard Time":      "America/Phoenix",
+	"Mountain Standard Time":         "America/Denver",
+	"Central Standard Time":          "America/Chicago",
+	"Eastern Standard Time":          "America/New_York",
+	"Atlantic Standard Time":         "America/Halifax",
+	"SA Pacific Standard Time":       "America/Bogota",
+	"E. South America Standard Time": "America/Sao_Paulo",
+	"UTC":                            "Etc/UTC",
+	"GMT Standard Time":              "Europe/London",
+	"Greenwich Standard Time":        "Atlantic/Reykjavik",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Romance Standard Time":          "Europe/Paris",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"Central European Standard Time": "Europe/Warsaw",
+	"GTB Standard Time":              "Europe/Bucharest",
+	"FLE Standard Time":              "Europe/Kiev",
+	"Russian Standard Time":          "Europe/Moscow",
+	"Arabian Standard Time":          "Asia/Dubai",
+	"India Standard Time":            "Asia/Kolkata",
+	"SE Asia Standard Time":          "Asia/Bangkok",
+	"China Standard Time":            "Asia/Shanghai",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"Taipei Standard Time":           "Asia/Taipei",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Korea Standard Time":            "Asia/Seoul",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"New Zealand Standard Time":      "Pacific/Auckland",
+}
+
+// loadTZID TZID パラメータのタイムゾーンを解決する（IANA 名、または Windows のタイムゾーン名）
+// 解決できない場合は日時を取り違えないようにエラーにする
+func loadTZID(tzid string) (*time.Location, error) {
+	if tzid != "Local" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			return loc, nil
+		}
+	}
+	if name, ok := windowsZones[tzid]; ok {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown TZID %q", tzid)
+}
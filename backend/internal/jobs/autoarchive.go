@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"time"
+
+	"career-schedule-api/internal/audit"
+
+	"gorm.io/gorm"
+)
+
+// AutoArchiveJobName 確定・キャンセル済み予定の自動アーカイブ
+const AutoArchiveJobName = "auto_archive_events"
+
+// autoArchiveSQL 確定・キャンセル済みの予定を自動アーカイブ
+// 基準（N はユーザー設定の auto_archive_delay_days、既定 1 = 翌日）:
+// - status = confirmed: confirmed_slot.end_time の N 日後以降になったもの
+// - status = rejected: updated_at の N 日後以降になったもの
+// いずれも is_archived = false でゴミ箱になく、ユーザー設定で自動アーカイブが有効（既定で有効）なものが対象
+// 日付の区切りはユーザー設定のタイムゾーン（未設定なら Asia/Tokyo）の当日0時基準で判定
+// JSONB の confirmed_slot->>'end_time' を timestamptz にキャストし、ユーザーのタイムゾーンに変換して比較
+// 監査ログに記録するため、更新前の archived_at を target.previous_archived_at として返せるようにしている
+const autoArchiveSQL = `
+    UPDATE events
+    SET is_archived = TRUE,
+        archived_at = NOW()
+    FROM (
+        SELECT e.id,
+               e.archived_at AS previous_archived_at,
+               COALESCE(s.timezone, 'Asia/Tokyo') AS tz,
+               make_interval(days => COALESCE(s.auto_archive_delay_days, 1) - 1) AS delay
+        FROM events e
+        LEFT JOIN user_settings s ON s.user_id = e.user_id
+        WHERE e.is_archived = FALSE
+          AND e.deleted_at IS NULL
+          AND COALESCE(s.auto_archive_events, TRUE)
+    ) AS target
+    WHERE events.id = target.id
+      AND (
+            (
+              events.status = 'confirmed'
+              AND events.confirmed_slot IS NOT NULL
+              AND (events.confirmed_slot->>'end_time') IS NOT NULL
+              AND (((events.confirmed_slot->>'end_time')::timestamptz AT TIME ZONE target.tz) < date_trunc('day', (now() AT TIME ZONE target.tz)) - target.delay)
+            )
+         OR (
+              events.status = 'rejected'
+              AND ((events.updated_at AT TIME ZONE target.tz) < date_trunc('day', (now() AT TIME ZONE target.tz)) - target.delay)
+            )
+      )
+`
+
+// autoArchiveReturning autoArchiveSQL の末尾（絞り込みの後）に付け、アーカイブした予定を返す
+const autoArchiveReturning = `
+    RETURNING events.id, events.user_id, events.archived_at, target.previous_archived_at`
+
+// archivedEvent 自動アーカイブした予定
+type archivedEvent struct {
+	ID                 string
+	UserID             string
+	ArchivedAt         time.Time
+	PreviousArchivedAt *time.Time
+}
+
+// archiveState 自動アーカイブの監査ログに記録する項目（予定のアーカイブと同じ項目名）
+type archiveState struct {
+	IsArchived bool       `json:"is_archived"`
+	ArchivedAt *time.Time `json:"archived_at"`
+}
+
+// ArchiveEvents 指定ユーザーの予定を自動アーカイブし、更新件数を返す
+// アーカイブした予定ごとに actor の操作として監査ログを同じトランザクションで記録する
+func ArchiveEvents(db *gorm.DB, userID string, actor audit.Actor) (int64, error) {
+	var updated int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		updated, err = archiveEvents(tx, func(archivedEvent) audit.Actor { return actor },
+			autoArchiveSQL+"      AND events.user_id = ?"+autoArchiveReturning, userID)
+		return err
+	})
+	return updated, err
+}
+
+// ArchiveAllEvents 全ユーザーの予定を自動アーカイブする（スケジューラー用）
+// 監査ログは予定の所有者の操作として（リクエストID・IPアドレスなしで）記録する
+func ArchiveAllEvents(tx *gorm.DB) (int64, error) {
+	return archiveEvents(tx, func(e archivedEvent) audit.Actor { return audit.Actor{UserID: e.UserID} },
+		autoArchiveSQL+autoArchiveReturning)
+}
+
+// archiveEvents 自動アーカイブを実行し、アーカイブした予定の監査ログを tx で一括して記録する
+func archiveEvents(tx *gorm.DB, actor func(archivedEvent) audit.Actor, query string, args ...interface{}) (int64, error) {
+	var archived []archivedEvent
+	if err := tx.Raw(query, args...).Scan(&archived).Error; err != nil {
+		return 0, err
+	}
+
+	entries := make([]audit.Entry, len(archived))
+	for i, e := range archived {
+		archivedAt := e.ArchivedAt
+		entries[i] = audit.Entry{
+			Actor:      actor(e),
+			EntityType: audit.EntityEvent,
+			EntityID:   e.ID,
+			Action:     audit.ActionArchive,
+			Before:     archiveState{IsArchived: false, ArchivedAt: e.PreviousArchivedAt},
+			After:      archiveState{IsArchived: true, ArchivedAt: &archivedAt},
+		}
+	}
+	if err := audit.RecordAll(tx, entries); err != nil {
+		return 0, err
+	}
+	return int64(len(archived)), nil
+}
@@ -0,0 +1,202 @@
+//go:build integration
+
+package jobs
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/database"
+	"career-schedule-api/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// 自動アーカイブの基準（autoArchiveSQL）を PostgreSQL で確認する
+// TEST_DATABASE_URL のデータベースにマイグレーションを適用し、テストのデータはロールバックする
+//
+//	TEST_DATABASE_URL=postgres://... go test -tags integration ./internal/jobs/
+
+const (
+	integrationUserA = "aaaaaaaa-1111-4111-8111-111111111111"
+	integrationUserB = "aaaaaaaa-2222-4222-8222-222222222222"
+	integrationUserC = "aaaaaaaa-3333-4333-8333-333333333333"
+)
+
+func integrationTx(t *testing.T) *gorm.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := database.New(url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if _, err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// transactionNow autoArchiveSQL の now()（トランザクションの開始時刻）と、既定のタイムゾーンでのその日の0時
+func transactionNow(t *testing.T, tx *gorm.DB) (now, today time.Time) {
+	t.Helper()
+	if err := tx.Raw("SELECT now()").Scan(&now).Error; err != nil {
+		t.Fatal(err)
+	}
+	loc, err := time.LoadLocation(models.DefaultTimezone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := now.In(loc)
+	return now, time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+func TestArchiveEvents(t *testing.T) {
+	tx := integrationTx(t)
+
+	now, today := transactionNow(t, tx)
+
+	put := func(userID, status string, end, updatedAt time.Time, modify func(*models.Event)) models.Event {
+		t.Helper()
+		event := models.Event{
+			UserID:      userID,
+			CompanyID:   "bbbbbbbb-0000-4000-8000-000000000000",
+			CompanyName: "Acme",
+			Title:       status,
+			Type:        "interview",
+			Status:      status,
+			CreatedAt:   updatedAt,
+			UpdatedAt:   updatedAt,
+		}
+		if !end.IsZero() {
+			event.ConfirmedSlot = datatypes.JSON(`{"start_time":"` + end.Add(-time.Hour).Format(time.RFC3339) + `","end_time":"` + end.Format(time.RFC3339) + `"}`)
+		}
+		if modify != nil {
+			modify(&event)
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+		return event
+	}
+
+	tests := []struct {
+		name  string
+		event models.Event
+		want  bool
+	}{
+		{"confirmed, ended yesterday", put(integrationUserA, "confirmed", today.Add(-time.Hour), now, nil), true},
+		{"confirmed, ended today", put(integrationUserA, "confirmed", today.Add(time.Hour), now, nil), false},
+		{"confirmed, not yet held", put(integrationUserA, "confirmed", now.Add(48*time.Hour), now, nil), false},
+		{"confirmed without slot", put(integrationUserA, "confirmed", time.Time{}, today.AddDate(0, 0, -7), nil), false},
+		{"rejected yesterday", put(integrationUserA, "rejected", time.Time{}, today.Add(-time.Minute), nil), true},
+		{"rejected today", put(integrationUserA, "rejected", time.Time{}, today.Add(time.Minute), nil), false},
+		{"candidate never archived", put(integrationUserA, "candidate", time.Time{}, today.AddDate(0, 0, -30), nil), false},
+		{"trashed", put(integrationUserA, "rejected", time.Time{}, today.AddDate(0, 0, -7), func(e *models.Event) {
+			e.DeletedAt = gorm.DeletedAt{Time: today.AddDate(0, 0, -1), Valid: true}
+		}), false},
+	}
+
+	actor := audit.Actor{UserID: integrationUserA, RequestID: "test-request"}
+	updated, err := ArchiveEvents(tx, integrationUserA, actor)
+	if err != nil {
+		t.Fatalf("ArchiveEvents: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("updated = %d, want 2", updated)
+	}
+	for _, tt := range tests {
+		var stored models.Event
+		if err := tx.Unscoped().First(&stored, "id = ?", tt.event.ID).Error; err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if stored.IsArchived != tt.want {
+			t.Errorf("%s: is_archived = %v, want %v", tt.name, stored.IsArchived, tt.want)
+		}
+		var audits []models.AuditLog
+		if err := tx.Where("entity_id = ? AND action = ?", tt.event.ID, audit.ActionArchive).Find(&audits).Error; err != nil {
+			t.Fatal(err)
+		}
+		if tt.want && (len(audits) != 1 || audits[0].UserID != integrationUserA || audits[0].RequestID != actor.RequestID) {
+			t.Errorf("%s: audits = %+v, want one archive by the actor", tt.name, audits)
+		}
+		if !tt.want && len(audits) != 0 {
+			t.Errorf("%s: audits = %+v, want none", tt.name, audits)
+		}
+	}
+}
+
+func TestArchiveAllEventsUsesEachUsersSettings(t *testing.T) {
+	tx := integrationTx(t)
+
+	_, today := transactionNow(t, tx)
+
+	slow := models.NewUserSettings(integrationUserB)
+	slow.AutoArchiveDelayDays = 3
+	disabled := models.NewUserSettings(integrationUserC)
+	disabled.AutoArchiveEvents = false
+	for _, settings := range []models.UserSettings{slow, disabled} {
+		if err := tx.Create(&settings).Error; err != nil {
+			t.Fatalf("create settings: %v", err)
+		}
+	}
+
+	put := func(userID string, updatedAt time.Time) models.Event {
+		t.Helper()
+		event := models.Event{
+			UserID:      userID,
+			CompanyID:   "bbbbbbbb-0000-4000-8000-000000000000",
+			CompanyName: "Acme",
+			Title:       "rejected",
+			Type:        "interview",
+			Status:      "rejected",
+			CreatedAt:   updatedAt,
+			UpdatedAt:   updatedAt,
+		}
+		if err := tx.Create(&event).Error; err != nil {
+			t.Fatalf("create event: %v", err)
+		}
+		return event
+	}
+
+	// auto_archive_delay_days = 3 なら2日前の0時より前に更新したものが対象
+	tests := []struct {
+		name  string
+		event models.Event
+		want  bool
+	}{
+		{"delay 3, within delay", put(integrationUserB, today.AddDate(0, 0, -2).Add(time.Hour)), false},
+		{"delay 3, past delay", put(integrationUserB, today.AddDate(0, 0, -2).Add(-time.Hour)), true},
+		{"disabled", put(integrationUserC, today.AddDate(0, 0, -30)), false},
+	}
+
+	if _, err := ArchiveAllEvents(tx); err != nil {
+		t.Fatalf("ArchiveAllEvents: %v", err)
+	}
+	for _, tt := range tests {
+		var stored models.Event
+		if err := tx.First(&stored, "id = ?", tt.event.ID).Error; err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if stored.IsArchived != tt.want {
+			t.Errorf("%s: is_archived = %v, want %v", tt.name, stored.IsArchived, tt.want)
+		}
+		var count int64
+		if err := tx.Model(&models.AuditLog{}).Where("entity_id = ? AND action = ? AND user_id = ?", tt.event.ID, audit.ActionArchive, tt.event.UserID).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if tt.want != (count == 1) || count > 1 {
+			t.Errorf("%s: audit logs = %d, want one only when archived", tt.name, count)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"career-schedule-api/internal/digest"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/notify"
+
+	"gorm.io/gorm"
+)
+
+// DigestJobName 日次ダイジェストメールの送信
+const DigestJobName = "send_digests"
+
+const digestSendTimeout = 30 * time.Second
+
+// DigestSender ダイジェストを有効にしたユーザーへ、設定した時刻を過ぎたら1日1回メールを送る
+//
+// 二重送信を防ぐため、スケジューラーのロック中に last_digest_at を更新してコミットしてから送信し、
+// 送信に失敗した場合は元に戻して次回の実行で再送する。予定が1件も無い日は送らない
+type DigestSender struct {
+	db       *gorm.DB
+	notifier notify.Notifier
+}
+
+func NewDigestSender(db *gorm.DB, notifier notify.Notifier) *DigestSender {
+	return &DigestSender{db: db, notifier: notifier}
+}
+
+// Claim 送信時刻を過ぎたユーザーの last_digest_at を更新して確保し、送信処理を返す（スケジューラー用）
+func (s *DigestSender) Claim(tx *gorm.DB) (SendFunc, error) {
+	var users []models.UserSettings
+	if err := tx.Where("digest_enabled = ? AND digest_email <> ''", true).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var claimed []models.UserSettings
+	for _, settings := range users {
+		if !digestDue(settings, now) {
+			continue
+		}
+		// 既に他の実行が処理した場合は送らない
+		claim := tx.Model(&models.UserSettings{}).Where("user_id = ?", settings.UserID)
+		if settings.LastDigestAt == nil {
+			claim = claim.Where("last_digest_at IS NULL")
+		} else {
+			claim = claim.Where("last_digest_at = ?", *settings.LastDigestAt)
+		}
+		result := claim.Update("last_digest_at", now)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			claimed = append(claimed, settings)
+		}
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+
+	return func() (int64, error) {
+		var sent int64
+		var errs []error
+		for _, settings := range claimed {
+			ok, err := s.send(settings, now)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("digest for user %s: %w", settings.UserID, err))
+			}
+			if ok {
+				sent++
+			}
+		}
+		return sent, errors.Join(errs...)
+	}, nil
+}
+
+// digestDue ユーザーのタイムゾーンで送信時刻を過ぎていて、今日はまだ処理していない
+func digestDue(settings models.UserSettings, now time.Time) bool {
+	loc := settings.Location()
+	local := now.In(loc)
+	if local.Hour() < settings.DigestHour {
+		return false
+	}
+	if settings.LastDigestAt == nil {
+		return true
+	}
+	last := settings.LastDigestAt.In(loc)
+	return last.Year() != local.Year() || last.YearDay() != local.YearDay()
+}
+
+// send 確保したユーザーのダイジェストを作成して送る。送信した場合は true
+// settings は確保前の値（失敗時に last_digest_at を戻すのに使う）
+func (s *DigestSender) send(settings models.UserSettings, now time.Time) (bool, error) {
+	d, err := digest.Build(s.db, settings, now)
+	if err == nil && d.Empty() {
+		return false, nil
+	}
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), digestSendTimeout)
+		err = s.notifier.Notify(ctx, notify.Message{To: settings.DigestEmail, Subject: d.Subject(), Body: d.Body()})
+		cancel()
+	}
+	if err != nil {
+		// 次回の実行で再送する
+		if rollback := s.db.Model(&models.UserSettings{}).Where("user_id = ?", settings.UserID).
+			Update("last_digest_at", settings.LastDigestAt).Error; rollback != nil {
+			return false, errors.Join(err, rollback)
+		}
+		return false, err
+	}
+	return true, nil
+}
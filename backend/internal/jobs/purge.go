@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"time"
+
+	"career-schedule-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PurgeJobName 保持期間を過ぎたゴミ箱の企業・予定の完全削除
+const PurgeJobName = "purge_trash"
+
+// TrashPurger ゴミ箱に移動してから retention を過ぎた企業・予定を、関連する履歴とともに完全に削除する
+type TrashPurger struct {
+	retention time.Duration
+}
+
+func NewTrashPurger(retention time.Duration) *TrashPurger {
+	return &TrashPurger{retention: retention}
+}
+
+// Run 完全に削除した企業・予定の件数を返す（スケジューラー用）
+func (p *TrashPurger) Run(tx *gorm.DB) (int64, error) {
+	cutoff := time.Now().Add(-p.retention)
+
+	// 予定: リマインダーの送信記録 → リマインダー → 予定の順に削除
+	expiredEvents := tx.Unscoped().Model(&models.Event{}).Select("id").Where("deleted_at < ?", cutoff)
+	expiredReminders := tx.Model(&models.Reminder{}).Select("id").Where("event_id IN (?)", expiredEvents)
+	if err := tx.Where("reminder_id IN (?)", expiredReminders).Delete(&models.ReminderDelivery{}).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("event_id IN (?)", expiredEvents).Delete(&models.Reminder{}).Error; err != nil {
+		return 0, err
+	}
+	events := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Event{})
+	if events.Error != nil {
+		return 0, events.Error
+	}
+
+	// 企業: 選考履歴 → 企業の順に削除
+	expiredCompanies := tx.Unscoped().Model(&models.Company{}).Select("id").Where("deleted_at < ?", cutoff)
+	if err := tx.Where("company_id IN (?)", expiredCompanies).Delete(&models.CompanyStageTransition{}).Error; err != nil {
+		return 0, err
+	}
+	companies := tx.Unscoped().Where("deleted_at < ?", cutoff).Delete(&models.Company{})
+	if companies.Error != nil {
+		return 0, companies.Error
+	}
+
+	return events.RowsAffected + companies.RowsAffected, nil
+}
@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"time"
+
+	"career-schedule-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxReminderOffsetMinutes リマインダーを設定できるのは開始の1週間前まで
+const maxReminderOffsetMinutes = 7 * 24 * 60
+
+// ReminderDefaults 予定の確定時に自動作成するリマインダー
+type ReminderDefaults struct {
+	Offsets []int // 開始の何分前に通知するか
+	Channel string
+}
+
+// NewReminderDefaults 範囲外・重複したオフセットを除き、未知のチャネルは log にする
+func NewReminderDefaults(offsets []int, channel string) ReminderDefaults {
+	switch channel {
+	case models.ReminderChannelLog, models.ReminderChannelEmail, models.ReminderChannelWebhook:
+	default:
+		log.Printf("Unknown reminder channel %q, falling back to %s", channel, models.ReminderChannelLog)
+		channel = models.ReminderChannelLog
+	}
+
+	seen := map[int]bool{}
+	valid := []int{}
+	for _, offset := range offsets {
+		if offset < 0 || offset > maxReminderOffsetMinutes || seen[offset] {
+			continue
+		}
+		seen[offset] = true
+		valid = append(valid, offset)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(valid)))
+
+	return ReminderDefaults{Offsets: valid, Channel: channel}
+}
+
+// valid 開始・終了が設定され、開始が終了より前であること
+func (s reminderSlot) valid() bool {
+	return !s.StartTime.IsZero() && !s.EndTime.IsZero() && s.StartTime.Before(s.EndTime)
+}
+
+// SyncReminders 予定の確定日時に合わせてリマインダーを作成・再計算する
+//   - 確定済みでリマインダーが無い場合: 既定のリマインダーを作成（通知時刻を過ぎているものは作らない）
+//   - 確定日時が変わった場合: 通知時刻を再計算して未送信に戻す（新しい通知時刻を過ぎているものは削除）
+//   - 確定済みでなくなった場合: 未送信のリマインダーを削除
+//
+// recipient は email チャネルの宛先。空の場合は log チャネルで作成する
+func SyncReminders(tx *gorm.DB, event models.Event, defaults ReminderDefaults, recipient string) error {
+	var confirmed reminderSlot
+	active := event.Status == "confirmed" && len(event.ConfirmedSlot) > 0 &&
+		json.Unmarshal(event.ConfirmedSlot, &confirmed) == nil && confirmed.valid()
+	if !active {
+		return tx.Where("event_id = ? AND status IN ?", event.ID, []string{models.ReminderPending, models.ReminderFailed}).
+			Delete(&models.Reminder{}).Error
+	}
+
+	var existing []models.Reminder
+	if err := tx.Where("event_id = ?", event.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if len(existing) == 0 {
+		channel := defaults.Channel
+		if channel == models.ReminderChannelEmail && recipient == "" {
+			channel = models.ReminderChannelLog
+		}
+		reminders := []models.Reminder{}
+		for _, offset := range defaults.Offsets {
+			remindAt := confirmed.StartTime.Add(-time.Duration(offset) * time.Minute)
+			if !remindAt.After(now) {
+				continue
+			}
+			reminders = append(reminders, models.Reminder{
+				EventID:       event.ID,
+				UserID:        event.UserID,
+				OffsetMinutes: offset,
+				Channel:       channel,
+				Recipient:     recipient,
+				RemindAt:      remindAt,
+				Status:        models.ReminderPending,
+				NextAttemptAt: remindAt,
+			})
+		}
+		if len(reminders) == 0 {
+			return nil
+		}
+		return tx.Create(&reminders).Error
+	}
+
+	for _, reminder := range existing {
+		remindAt := confirmed.StartTime.Add(-time.Duration(reminder.OffsetMinutes) * time.Minute)
+		if remindAt.Equal(reminder.RemindAt) {
+			continue
+		}
+		if !remindAt.After(now) {
+			if err := tx.Delete(&reminder).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		reminder.RemindAt = remindAt
+		reminder.NextAttemptAt = remindAt
+		reminder.Status = models.ReminderPending
+		reminder.Attempts = 0
+		reminder.SentAt = nil
+		reminder.LastError = ""
+		if err := tx.Save(&reminder).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"time"
+
+	"career-schedule-api/internal/emailtemplate"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/notify"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ReminderJobName 送信時刻を過ぎたリマインダーの送信
+const ReminderJobName = "dispatch_reminders"
+
+const (
+	// 1回の実行で送信するリマインダーの上限
+	reminderBatchSize = 100
+	// 送信を諦めるまでの試行回数（再送間隔は 1, 2, 4, 8 分）
+	reminderMaxAttempts = 5
+	reminderSendTimeout = 30 * time.Second
+	// 確保してから結果を記録するまでの猶予（過ぎても sending のままなら停止したものとみなして再送する）
+	reminderClaimLease = 30 * time.Minute
+)
+
+// ReminderDispatcher 送信時刻を過ぎたリマインダーをチャネルごとの Notifier で送る
+//
+// 二重送信を防ぐため、スケジューラーのロック中に status を sending、next_attempt_at を reminderClaimLease 後に
+// 更新してコミットしてから送信し、送信後に sent / pending（再送待ち）/ failed を記録する。
+// 結果の記録前にプロセスが停止した場合はその時刻を過ぎてから再送する
+type ReminderDispatcher struct {
+	db        *gorm.DB
+	notifiers map[string]notify.Notifier
+}
+
+// NewReminderDispatcher notifiers のキーはチャネル名（models.ReminderChannel*）
+func NewReminderDispatcher(db *gorm.DB, notifiers map[string]notify.Notifier) *ReminderDispatcher {
+	return &ReminderDispatcher{db: db, notifiers: notifiers}
+}
+
+// dueReminder 送信対象のリマインダーと、通知本文に使う予定の情報
+type dueReminder struct {
+	models.Reminder
+	CompanyName   string
+	Title         string
+	Location      string
+	IsOnline      bool
+	ConfirmedSlot datatypes.JSON
+	Timezone      string
+}
+
+type reminderSlot struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// Claim 送信時刻を過ぎたリマインダーを送信中として確保し、送信処理を返す（スケジューラー用）
+// 確定済みでアーカイブ・ゴミ箱への移動がされていない予定のリマインダーのみが対象
+func (d *ReminderDispatcher) Claim(tx *gorm.DB) (SendFunc, error) {
+	now := time.Now()
+	var due []dueReminder
+	if err := tx.Table("reminders AS r").
+		Select("r.*, e.company_name, e.title, e.location, e.is_online, e.confirmed_slot, COALESCE(s.timezone, ?) AS timezone", models.DefaultTimezone).
+		Joins("JOIN events e ON e.id = r.event_id").
+		Joins("LEFT JOIN user_settings s ON s.user_id = r.user_id").
+		Where("r.status IN ? AND r.next_attempt_at <= ?", []string{models.ReminderPending, models.ReminderSending}, now).
+		Where("e.status = ? AND e.is_archived = ? AND e.deleted_at IS NULL", "confirmed", false).
+		Order("r.next_attempt_at ASC").
+		Limit(reminderBatchSize).
+		Scan(&due).Error; err != nil {
+		return nil, err
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(due))
+	for i, r := range due {
+		ids[i] = r.ID
+	}
+	if err := tx.Model(&models.Reminder{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":          models.ReminderSending,
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": now.Add(reminderClaimLease),
+			"updated_at":      now,
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	return func() (int64, error) {
+		var sent int64
+		var errs []error
+		for _, r := range due {
+			ok, err := d.dispatch(r)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("reminder %s: %w", r.ID, err))
+			}
+			if ok {
+				sent++
+			}
+		}
+		return sent, errors.Join(errs...)
+	}, nil
+}
+
+// dispatch 確保した1件を送信して結果を記録する。送信できた場合は true
+// 返すエラーは記録の失敗のみ（送信の失敗は再送待ちとして記録する）
+func (d *ReminderDispatcher) dispatch(r dueReminder) (bool, error) {
+	attempt := r.Attempts + 1 // 確保時に加算した試行回数
+
+	var slot reminderSlot
+	if err := json.Unmarshal(r.ConfirmedSlot, &slot); err != nil || slot.StartTime.IsZero() {
+		return false, d.record(r.Reminder, attempt, errors.New("invalid confirmed slot"), true)
+	}
+	if !slot.StartTime.After(time.Now()) {
+		// 停止していた間に開始時刻を過ぎたものは送らない
+		return false, d.record(r.Reminder, attempt, errors.New("event has already started"), true)
+	}
+
+	notifier, ok := d.notifiers[r.Channel]
+	var sendErr error
+	if !ok {
+		sendErr = fmt.Errorf("notifier for channel %q is not configured", r.Channel)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), reminderSendTimeout)
+		sendErr = notifier.Notify(ctx, reminderMessage(r, slot))
+		cancel()
+	}
+	return sendErr == nil, d.record(r.Reminder, attempt, sendErr, false)
+}
+
+// record 送信結果をリマインダーと送信記録に保存する
+// 失敗時は上限回数まで指数バックオフで再送待ちに戻す（final の場合は再送しない）
+func (d *ReminderDispatcher) record(r models.Reminder, attempt int, sendErr error, final bool) error {
+	now := time.Now()
+	updates := map[string]interface{}{"updated_at": now}
+	delivery := models.ReminderDelivery{
+		ReminderID: r.ID,
+		Channel:    r.Channel,
+		Attempt:    attempt,
+		Status:     models.ReminderSent,
+	}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.ReminderSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+	case final || attempt >= reminderMaxAttempts:
+		updates["status"] = models.ReminderFailed
+		updates["last_error"] = sendErr.Error()
+		delivery.Status = models.ReminderFailed
+		delivery.Error = sendErr.Error()
+		log.Printf("Reminder %s failed after %d attempts: %v", r.ID, attempt, sendErr)
+	default:
+		updates["status"] = models.ReminderPending
+		updates["last_error"] = sendErr.Error()
+		updates["next_attempt_at"] = now.Add(time.Duration(1<<(attempt-1)) * time.Minute)
+		delivery.Status = models.ReminderFailed
+		delivery.Error = sendErr.Error()
+	}
+
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&delivery).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Reminder{}).Where("id = ?", r.ID).Updates(updates).Error
+	})
+}
+
+// reminderMessage リマインダーの通知内容（保存時にHTMLエスケープしているため戻して使う）
+func reminderMessage(r dueReminder, slot reminderSlot) notify.Message {
+	companyName := html.UnescapeString(r.CompanyName)
+	title := html.UnescapeString(r.Title)
+	location := html.UnescapeString(r.Location)
+	if r.IsOnline && location == "" {
+		location = "オンライン"
+	}
+	when := emailtemplate.FormatSlot(emailtemplate.Slot{Start: slot.StartTime, End: slot.EndTime}, models.UserSettings{Timezone: r.Timezone}.Location())
+
+	lead := "まもなく始まります。"
+	if r.OffsetMinutes > 0 {
+		lead = emailtemplate.FormatDuration(r.OffsetMinutes) + "後に始まります。"
+	}
+	body := companyName + "「" + title + "」が" + lead + "\n\n日時: " + when
+	if location != "" {
+		body += "\n場所: " + location
+	}
+
+	return notify.Message{
+		To:      r.Recipient,
+		Subject: "【リマインド】" + companyName + " " + title,
+		Body:    body,
+		Payload: map[string]interface{}{
+			"type":           "event.reminder",
+			"reminder_id":    r.ID,
+			"event_id":       r.EventID,
+			"company_name":   companyName,
+			"title":          title,
+			"location":       location,
+			"is_online":      r.IsOnline,
+			"start_time":     slot.StartTime,
+			"end_time":       slot.EndTime,
+			"offset_minutes": r.OffsetMinutes,
+			"text":           body,
+		},
+	}
+}
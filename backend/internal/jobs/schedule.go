@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule cron 形式（分 時 日 月 曜日）または @every / @hourly / @daily のスケジュール
+type Schedule struct {
+	every   time.Duration
+	minutes fieldSet
+	hours   fieldSet
+	days    fieldSet
+	months  fieldSet
+	weekday fieldSet
+	loc     *time.Location
+}
+
+// fieldSet cron の1フィールドで許可される値（any は * 指定）
+type fieldSet struct {
+	values map[int]bool
+	any    bool
+}
+
+func (f fieldSet) has(v int) bool {
+	return f.any || f.values[v]
+}
+
+// ParseSchedule スケジュール文字列を解釈する。cron 形式の時刻は loc で評価する
+//
+//	"5 * * * *"      毎時5分
+//	"0 9 * * 1-5"    平日9時
+//	"*/15 * * * *"   15分ごと
+//	"@every 30m"     30分ごと
+func ParseSchedule(spec string, loc *time.Location) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || d < time.Minute {
+			return nil, fmt.Errorf("invalid schedule %q: @every requires a duration of at least 1m", spec)
+		}
+		return &Schedule{every: d}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields", spec)
+	}
+
+	s := &Schedule{loc: loc}
+	var err error
+	if s.minutes, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: minute: %w", spec, err)
+	}
+	if s.hours, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: hour: %w", spec, err)
+	}
+	if s.days, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of month: %w", spec, err)
+	}
+	if s.months, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: month: %w", spec, err)
+	}
+	if s.weekday, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: day of week: %w", spec, err)
+	}
+	// 曜日の 7 は日曜日として扱う
+	if s.weekday.values[7] {
+		s.weekday.values[0] = true
+	}
+	if s.loc == nil {
+		s.loc = time.UTC
+	}
+	return s, nil
+}
+
+// parseField "*", "*/n", "a", "a-b", "a-b/n" とそのカンマ区切りを解釈する
+func parseField(field string, min, max int) (fieldSet, error) {
+	if field == "*" {
+		return fieldSet{any: true}, nil
+	}
+
+	set := fieldSet{values: map[int]bool{}}
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return set, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			n, err := strconv.Atoi(from)
+			if err != nil {
+				return set, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = n, n
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return set, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return set, fmt.Errorf("value out of range %q", part)
+		}
+		for v := lo; v <= hi; v += step {
+			set.values[v] = true
+		}
+	}
+	return set, nil
+}
+
+// dayMatches 日と曜日の両方が指定された場合はどちらかに一致すればよい（cron と同じ）
+func (s *Schedule) dayMatches(t time.Time) bool {
+	if !s.days.any && !s.weekday.any {
+		return s.days.has(t.Day()) || s.weekday.has(int(t.Weekday()))
+	}
+	return s.days.has(t.Day()) && s.weekday.has(int(t.Weekday()))
+}
+
+// Next after より後の次回実行時刻
+func (s *Schedule) Next(after time.Time) time.Time {
+	if s.every > 0 {
+		return after.Add(s.every)
+	}
+
+	t := after.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	// 該当しない月・日・時はまとめて読み飛ばす（5年分探して見つからなければ諦める）
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !s.months.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.hours.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.minutes.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,156 @@
+// Package jobs はサーバー内で定期実行するバックグラウンドジョブを扱う
+package jobs
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"career-schedule-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// JobFunc ジョブ本体。アドバイザリーロックを保持したトランザクション内で呼ばれ、処理件数を返す
+type JobFunc func(tx *gorm.DB) (int64, error)
+
+// ClaimFunc 外部への送信を伴うジョブ。アドバイザリーロックを保持したトランザクション内で処理対象を確保し
+// （送信中の状態に更新する）、コミット後にロックの外で実行する送信処理を返す（対象が無ければ nil）
+// 送信に時間がかかってもトランザクションを開いたままコネクションを占有せず、
+// 途中でプロセスが停止しても確保済みのものを次の実行がすぐに送り直さない
+type ClaimFunc func(tx *gorm.DB) (SendFunc, error)
+
+// SendFunc ClaimFunc で確保したものを送信して結果を記録し、処理件数を返す
+type SendFunc func() (int64, error)
+
+type job struct {
+	name     string
+	schedule *Schedule
+	run      JobFunc
+	claim    ClaimFunc
+}
+
+// Scheduler 登録されたジョブをスケジュールに従って実行する
+// 複数レプリカで同時に動いても、PostgreSQL のアドバイザリーロックで1つだけが実行する
+type Scheduler struct {
+	db   *gorm.DB
+	jobs []job
+	wg   sync.WaitGroup
+}
+
+func NewScheduler(db *gorm.DB) *Scheduler {
+	return &Scheduler{db: db}
+}
+
+// Add ジョブを登録する（Start より前に呼ぶ）
+func (s *Scheduler) Add(name string, schedule *Schedule, run JobFunc) {
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, run: run})
+}
+
+// AddClaim 確保と送信に分けたジョブを登録する（Start より前に呼ぶ）
+func (s *Scheduler) AddClaim(name string, schedule *Schedule, claim ClaimFunc) {
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, claim: claim})
+}
+
+// Start 各ジョブのループを開始する。ctx がキャンセルされると停止する
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, j)
+	}
+}
+
+// Wait 全ジョブのループが終了するまで待つ
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	defer s.wg.Done()
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("Scheduler: job %s has no next run, stopping", j.name)
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.RunNow(ctx, j.name)
+		}
+	}
+}
+
+// lockKey ジョブ名からアドバイザリーロックのキーを作る
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("career-schedule-api/jobs/" + name))
+	return int64(h.Sum64())
+}
+
+// RunNow ジョブを即時実行して結果を記録する。他のレプリカが実行中の場合は何もしない
+// PgBouncer のトランザクションプーリングでも使えるよう、セッションロックではなく
+// トランザクションスコープのロック（pg_try_advisory_xact_lock）を使う
+// AddClaim で登録したジョブは、ロック中に確保してコミットした後に送信する
+func (s *Scheduler) RunNow(ctx context.Context, name string) {
+	var target *job
+	for i := range s.jobs {
+		if s.jobs[i].name == name {
+			target = &s.jobs[i]
+		}
+	}
+	if target == nil {
+		return
+	}
+
+	startedAt := time.Now()
+	acquired := false
+	var rows int64
+	var send SendFunc
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", lockKey(name)).Scan(&acquired).Error; err != nil {
+			return err
+		}
+		if !acquired {
+			return nil
+		}
+		var err error
+		if target.claim != nil {
+			send, err = target.claim(tx)
+			return err
+		}
+		rows, err = target.run(tx)
+		return err
+	})
+	if err == nil && !acquired {
+		log.Printf("Scheduler: job %s is running on another instance, skipped", name)
+		return
+	}
+	if err == nil && send != nil {
+		rows, err = send()
+	}
+
+	finishedAt := time.Now()
+	run := models.JobRun{
+		JobName:      name,
+		Status:       models.JobRunSucceeded,
+		StartedAt:    startedAt,
+		FinishedAt:   &finishedAt,
+		RowsAffected: rows,
+	}
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = err.Error()
+		log.Printf("Scheduler: job %s failed: %v", name, err)
+	} else {
+		log.Printf("Scheduler: job %s finished (%d rows, %s)", name, rows, finishedAt.Sub(startedAt))
+	}
+	if err := s.db.Create(&run).Error; err != nil {
+		log.Printf("Scheduler: failed to record run of job %s: %v", name, err)
+	}
+}
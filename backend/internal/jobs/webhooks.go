@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/webhooks"
+
+	"gorm.io/gorm"
+)
+
+// WebhookJobName アウトボックスに積まれた Webhook の送信
+const WebhookJobName = "deliver_webhooks"
+
+const (
+	// 1回の実行で送信する件数の上限
+	webhookBatchSize = 100
+	// 送信を諦めるまでの試行回数（再送間隔は 1分から倍々で最大6時間）
+	webhookMaxAttempts = 10
+	webhookMaxBackoff  = 6 * time.Hour
+	// 履歴に残すレスポンス本文の長さ
+	webhookResponseLimit = 1024
+	// 確保してから結果を記録するまでの猶予（過ぎても sending のままなら停止したものとみなして再送する）
+	webhookClaimLease = 30 * time.Minute
+)
+
+// WebhookDispatcher アウトボックスの送信待ちを Webhook に POST する
+// 2xx が返るまで指数バックオフで再送する（少なくとも1回の送信。受信側は X-Webhook-Delivery で重複を除く）
+// 送信前に status を sending、next_attempt_at を webhookClaimLease 後に更新してコミットし、
+// 結果を記録する前にプロセスが停止した場合はその時刻を過ぎてから再送する
+type WebhookDispatcher struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+func NewWebhookDispatcher(db *gorm.DB, client *http.Client) *WebhookDispatcher {
+	return &WebhookDispatcher{db: db, client: client}
+}
+
+// dueDelivery 送信対象と送信先の Webhook
+type dueDelivery struct {
+	models.WebhookDelivery
+	URL      string
+	Secret   string
+	IsActive bool
+}
+
+// Claim 送信時刻を過ぎたものを送信中として確保し、送信処理を返す（スケジューラー用）
+func (d *WebhookDispatcher) Claim(tx *gorm.DB) (SendFunc, error) {
+	now := time.Now()
+	var due []dueDelivery
+	if err := tx.Table("webhook_deliveries AS d").
+		Select("d.*, w.url, w.secret, w.is_active").
+		Joins("JOIN webhooks w ON w.id = d.webhook_id").
+		Where("d.status IN ? AND d.next_attempt_at <= ?", []string{models.WebhookDeliveryPending, models.WebhookDeliverySending}, now).
+		Order("d.next_attempt_at ASC, d.created_at ASC").
+		Limit(webhookBatchSize).
+		Scan(&due).Error; err != nil {
+		return nil, err
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(due))
+	for i, delivery := range due {
+		ids[i] = delivery.ID
+	}
+	if err := tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":          models.WebhookDeliverySending,
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": now.Add(webhookClaimLease),
+		"updated_at":      now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return func() (int64, error) {
+		var delivered int64
+		var errs []error
+		for _, delivery := range due {
+			ok, err := d.deliver(delivery)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("webhook delivery %s: %w", delivery.ID, err))
+			}
+			if ok {
+				delivered++
+			}
+		}
+		return delivered, errors.Join(errs...)
+	}, nil
+}
+
+// deliver 確保した1件を送信して結果を記録する。届いた場合は true
+// 返すエラーは記録の失敗のみ（送信の失敗は再送待ちとして記録する）
+func (d *WebhookDispatcher) deliver(delivery dueDelivery) (bool, error) {
+	attempt := delivery.Attempts + 1 // 確保時に加算した試行回数
+	if !delivery.IsActive {
+		return false, d.record(delivery.ID, attempt, 0, "", errors.New("webhook is disabled"), true)
+	}
+
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return false, d.record(delivery.ID, attempt, 0, "", err, true)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "career-schedule-api-webhooks")
+	req.Header.Set(webhooks.HeaderEvent, delivery.EventKind)
+	req.Header.Set(webhooks.HeaderDelivery, delivery.ID)
+	req.Header.Set(webhooks.HeaderTimestamp, fmt.Sprint(timestamp))
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return false, d.record(delivery.ID, attempt, 0, "", err, false)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	response := string(body)
+	if !utf8.ValidString(response) {
+		response = ""
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, d.record(delivery.ID, attempt, resp.StatusCode, response, fmt.Errorf("unexpected status %d", resp.StatusCode), false)
+	}
+	return true, d.record(delivery.ID, attempt, resp.StatusCode, response, nil, false)
+}
+
+// record 送信結果を保存する。失敗時は上限回数まで再送待ちに戻す（final の場合は再送しない）
+func (d *WebhookDispatcher) record(id string, attempt, statusCode int, response string, sendErr error, final bool) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"attempts":         attempt,
+		"last_status_code": statusCode,
+		"last_response":    response,
+		"updated_at":       now,
+	}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.WebhookDeliveryDelivered
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+	case final || attempt >= webhookMaxAttempts:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		log.Printf("Webhook delivery %s failed after %d attempts: %v", id, attempt, sendErr)
+	default:
+		backoff := time.Minute << (attempt - 1)
+		if backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+		updates["status"] = models.WebhookDeliveryPending
+		updates["last_error"] = sendErr.Error()
+		updates["next_attempt_at"] = now.Add(backoff)
+	}
+
+	return d.db.Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(updates).Error
+}
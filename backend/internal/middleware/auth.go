@@ -1,12 +1,24 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+
+	"career-schedule-api/internal/apitoken"
+	"career-schedule-api/internal/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// 認証方法（コンテキストの auth_method）
+const (
+	AuthMethodSession  = "session"
+	AuthMethodAPIToken = "api_token"
 )
 
 // SupabaseJWTClaims represents the claims in a Supabase JWT token
@@ -16,7 +28,38 @@ type SupabaseJWTClaims struct {
 	jwt.RegisteredClaims
 }
 
-func Auth(jwtSecret string) gin.HandlerFunc {
+// JWTVerifier Supabase の JWT の検証設定
+// 非対称鍵（RS256 / ES256 など）は JWKS の公開鍵で、HS256 などは Secret で検証する（それぞれ未設定なら受け付けない）
+type JWTVerifier struct {
+	Secret   string
+	JWKS     *JWKS
+	Audience string // 空なら aud を検証しない
+	Issuer   string // 空なら iss を検証しない
+}
+
+// jwtLeeway exp / nbf / iat の判定で許容する時計のずれ
+const jwtLeeway = 30 * time.Second
+
+var (
+	hmacMethods       = []string{"HS256", "HS384", "HS512"}
+	asymmetricMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+// methods 受け付ける署名アルゴリズム
+func (v *JWTVerifier) methods() []string {
+	var methods []string
+	if v.JWKS != nil {
+		methods = append(methods, asymmetricMethods...)
+	}
+	if v.Secret != "" {
+		methods = append(methods, hmacMethods...)
+	}
+	return methods
+}
+
+// Auth Supabase の JWT、または個人用アクセストークン（csat_ で始まるもの）で認証し、user_id を設定する
+// 個人用アクセストークンは db で照合し、read 権限のものは参照系のメソッドのみ許可する
+func Auth(verifier *JWTVerifier, db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -35,49 +78,120 @@ func Auth(jwtSecret string) gin.HandlerFunc {
 
 		tokenString := tokenParts[1]
 
-		// Check if JWT secret is configured
-		if jwtSecret == "" {
+		if apitoken.IsToken(tokenString) {
+			authenticateAPIToken(c, db, tokenString)
+			return
+		}
+
+		// Check if JWT verification is configured
+		if verifier.Secret == "" && verifier.JWKS == nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "JWT secret not configured on server"})
 			c.Abort()
 			return
 		}
 
 		// Parse and validate the JWT token
-		userID, err := validateSupabaseJWT(tokenString, jwtSecret)
+		claims, err := validateSupabaseJWT(c.Request.Context(), tokenString, verifier)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
 			c.Abort()
 			return
 		}
 
-		// Set the user ID in the context
-		c.Set("user_id", userID)
+		// Set the user ID and email in the context
+		c.Set("user_id", claims.Sub)
+		c.Set("user_email", claims.Email)
+		c.Set("auth_method", AuthMethodSession)
 		c.Next()
 	}
 }
 
-// validateSupabaseJWT validates a Supabase JWT token and returns the user ID
-func validateSupabaseJWT(tokenString, jwtSecret string) (string, error) {
+// authenticateAPIToken 個人用アクセストークンで認証して次のハンドラーを呼ぶ
+func authenticateAPIToken(c *gin.Context, db *gorm.DB, tokenString string) {
+	if db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+		c.Abort()
+		return
+	}
+
+	token, err := apitoken.Authenticate(db, tokenString)
+	if err != nil {
+		if err == apitoken.ErrInvalid || err == apitoken.ErrExpired {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+			c.Abort()
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify API token"})
+		c.Abort()
+		return
+	}
+
+	if token.Scope != models.APITokenScopeWrite {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			c.JSON(http.StatusForbidden, gin.H{"error": "API token does not have write scope"})
+			c.Abort()
+			return
+		}
+	}
+
+	c.Set("user_id", token.UserID)
+	c.Set("user_email", token.Email)
+	c.Set("auth_method", AuthMethodAPIToken)
+	c.Set("api_token_id", token.ID)
+	c.Next()
+}
+
+// RequireSession ログインセッション（JWT）での認証のみ許可する（Auth の後に使う）
+// トークンの発行など、個人用アクセストークンから行わせない操作に使う
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") != AuthMethodSession {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint requires a signed-in session"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// validateSupabaseJWT validates a Supabase JWT token and returns its claims
+// The signature, exp (required), and aud / iss (when configured) are verified
+func validateSupabaseJWT(ctx context.Context, tokenString string, verifier *JWTVerifier) (*SupabaseJWTClaims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(verifier.methods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtLeeway),
+	}
+	if verifier.Audience != "" {
+		options = append(options, jwt.WithAudience(verifier.Audience))
+	}
+	if verifier.Issuer != "" {
+		options = append(options, jwt.WithIssuer(verifier.Issuer))
+	}
+
 	// Parse the token
 	token, err := jwt.ParseWithClaims(tokenString, &SupabaseJWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		// Select the key by signing method (allowed methods are checked by the parser)
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return []byte(verifier.Secret), nil
 		}
-		return []byte(jwtSecret), nil
-	})
+		kid, _ := token.Header["kid"].(string)
+		return verifier.JWKS.Key(ctx, kid)
+	}, options...)
 
 	if err != nil {
-		return "", fmt.Errorf("failed to parse token: %w", err)
+		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	// Extract claims
 	if claims, ok := token.Claims.(*SupabaseJWTClaims); ok && token.Valid {
 		if claims.Sub == "" {
-			return "", fmt.Errorf("missing user ID in token")
+			return nil, fmt.Errorf("missing user ID in token")
 		}
-		return claims.Sub, nil
+		return claims, nil
 	}
 
-	return "", fmt.Errorf("invalid token claims")
+	return nil, fmt.Errorf("invalid token claims")
 }
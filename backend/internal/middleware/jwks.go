@@ -0,0 +1,307 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksFetchTimeout JWKS の取得のタイムアウト
+	jwksFetchTimeout = 10 * time.Second
+	// jwksMaxBytes JWKS として読み込む最大サイズ
+	jwksMaxBytes = 1 << 20
+	// jwksMinRefreshInterval 未知の kid による再取得の最短間隔（不正なトークンで取得元に負荷をかけないため）
+	jwksMinRefreshInterval = 30 * time.Second
+)
+
+// ErrUnknownKey JWKS に kid の鍵が無い
+var ErrUnknownKey = errors.New("signing key not found in JWKS")
+
+// JWKS 署名検証用の公開鍵セット（JSON Web Key Set）のキャッシュ
+// source は https:// などの URL、または file:// / パス指定のローカルファイル（オフラインでの検証用）
+type JWKS struct {
+	source string
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+
+	refreshMu sync.Mutex
+}
+
+func NewJWKS(source string) *JWKS {
+	return &JWKS{
+		source: source,
+		client: &http.Client{Timeout: jwksFetchTimeout},
+		keys:   map[string]interface{}{},
+	}
+}
+
+// Key kid の公開鍵を返す。見つからない場合は JWKS を取得し直してから探す（鍵のローテーション対応）
+// kid が空のトークンは、鍵が1つだけの場合にその鍵を使う
+func (j *JWKS) Key(ctx context.Context, kid string) (interface{}, error) {
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+	if err := j.refreshIfStale(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (j *JWKS) lookup(kid string) (interface{}, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if kid == "" {
+		if len(j.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// refreshIfStale 前回の取得から jwksMinRefreshInterval 以上経っていれば取得し直す
+func (j *JWKS) refreshIfStale(ctx context.Context) error {
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+
+	j.mu.RLock()
+	fresh := !j.fetchedAt.IsZero() && time.Since(j.fetchedAt) < jwksMinRefreshInterval
+	j.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+	return j.refreshLocked(ctx)
+}
+
+// Refresh JWKS を取得し直して鍵を入れ替える
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.refreshMu.Lock()
+	defer j.refreshMu.Unlock()
+	return j.refreshLocked(ctx)
+}
+
+func (j *JWKS) refreshLocked(ctx context.Context) error {
+	body, err := j.fetch(ctx)
+	if err != nil {
+		// 取得に失敗しても、連続して取得しに行かないよう時刻は更新する（既存の鍵はそのまま使う）
+		j.mu.Lock()
+		j.fetchedAt = time.Now()
+		j.mu.Unlock()
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	keys, err := parseJWKS(body)
+	if err != nil {
+		j.mu.Lock()
+		j.fetchedAt = time.Now()
+		j.mu.Unlock()
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	j.mu.Unlock()
+	return nil
+}
+
+func (j *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	if path, ok := localJWKSPath(j.source); ok {
+		return os.ReadFile(path)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, jwksMaxBytes))
+}
+
+// localJWKSPath file:// またはスキームなしの指定ならローカルファイルのパスを返す
+func localJWKSPath(source string) (string, bool) {
+	if strings.HasPrefix(source, "file://") {
+		return strings.TrimPrefix(source, "file://"), true
+	}
+	if !strings.Contains(source, "://") {
+		return source, true
+	}
+	return "", false
+}
+
+// Start interval ごとに JWKS を取得し直す（鍵のローテーション対応）。ctx がキャンセルされると停止する
+// interval が0以下なら定期的には取得しない（未知の kid を受け取った時のみ取得する）
+func (j *JWKS) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := j.Refresh(ctx); err != nil {
+					log.Printf("JWKS: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// jwk JSON Web Key（公開鍵のみ扱う）
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS 署名用の鍵を kid ごとに取り出す（対応していない種類の鍵は読み飛ばす）
+func parseJWKS(body []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			log.Printf("JWKS: skipping key %q: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	if !k.algMatches() {
+		return nil, fmt.Errorf("alg %q does not match kty %q", k.Alg, k.Kty)
+	}
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid e: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x: %w", err)
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid x")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// algMatches alg が指定されている場合、鍵の種類（EC は曲線も）で使えるアルゴリズムか
+func (k jwk) algMatches() bool {
+	if k.Alg == "" {
+		return true
+	}
+	switch k.Kty {
+	case "RSA":
+		return strings.HasPrefix(k.Alg, "RS") || strings.HasPrefix(k.Alg, "PS")
+	case "EC":
+		return k.Alg == map[string]string{"P-256": "ES256", "P-384": "ES384", "P-521": "ES512"}[k.Crv]
+	case "OKP":
+		return k.Alg == "EdDSA"
+	default:
+		return false
+	}
+}
+
+// decodeJWKInt base64url（パディングなし）の符号なし整数
+func decodeJWKInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
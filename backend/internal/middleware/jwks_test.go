@@ -0,0 +1,288 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	testKeysOnce sync.Once
+	testRSAKey   *rsa.PrivateKey
+	testECKey    *ecdsa.PrivateKey
+)
+
+// testKeys テストで使う RSA / EC の鍵（生成に時間がかかるため1度だけ作る）
+func testKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	t.Helper()
+	testKeysOnce.Do(func() {
+		var err error
+		if testRSAKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+		if testECKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			panic(err)
+		}
+	})
+	return testRSAKey, testECKey
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "RSA", "kid": kid, "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))}
+}
+
+// with JWK に項目を追加・上書きしたもの
+func with(k map[string]string, kv ...string) map[string]string {
+	out := map[string]string{}
+	for name, v := range k {
+		out[name] = v
+	}
+	for i := 0; i+1 < len(kv); i += 2 {
+		out[kv[i]] = kv[i+1]
+	}
+	return out
+}
+
+func jwksBody(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, ecKey := testKeys(t)
+	rsaPub := rsaJWK("rsa", &rsaKey.PublicKey)
+	ecPub := ecJWK("ec", &ecKey.PublicKey)
+
+	tests := []struct {
+		name string
+		key  map[string]string
+		want interface{} // nil なら読み飛ばす
+	}{
+		{"RSA", rsaPub, &rsaKey.PublicKey},
+		{"RSA with alg", with(rsaPub, "alg", "RS256", "use", "sig"), &rsaKey.PublicKey},
+		{"EC P-256", ecPub, &ecKey.PublicKey},
+		{"EC with alg", with(ecPub, "alg", "ES256"), &ecKey.PublicKey},
+		{"encryption key", with(rsaPub, "use", "enc"), nil},
+		{"RSA with EC alg", with(rsaPub, "alg", "ES256"), nil},
+		{"EC with RSA alg", with(ecPub, "alg", "RS256"), nil},
+		{"EC with alg of another curve", with(ecPub, "alg", "ES384"), nil},
+		{"EC with curve of another key size", with(ecPub, "crv", "P-384"), nil},
+		{"EC point not on curve", with(ecPub, "y", b64([]byte{1})), nil},
+		{"RSA without exponent", with(rsaPub, "e", ""), nil},
+		{"unsupported kty", with(rsaPub, "kty", "oct"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseJWKS(jwksBody(t, tt.key))
+			if err != nil {
+				t.Fatalf("parseJWKS: %v", err)
+			}
+			got, ok := keys[tt.key["kid"]]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("key = %T, want it skipped", got)
+				}
+				return
+			}
+			equal, _ := got.(interface{ Equal(crypto.PublicKey) bool })
+			if !ok || equal == nil || !equal.Equal(tt.want) {
+				t.Errorf("key = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseJWKS([]byte("not json")); err == nil {
+		t.Error("parseJWKS(invalid JSON) = nil error, want an error")
+	}
+}
+
+// jwksServer keys を JWKS として返すサーバー（取得回数を数える）
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	body     []byte
+	requests int
+}
+
+func newJWKSServer(t *testing.T, body []byte) *jwksServer {
+	s := &jwksServer{body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		w.Write(s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func TestJWKSKeyRefreshesUnknownKid(t *testing.T) {
+	rsaKey, ecKey := testKeys(t)
+	server := newJWKSServer(t, jwksBody(t, rsaJWK("old", &rsaKey.PublicKey)))
+	jwks := NewJWKS(server.URL)
+	ctx := context.Background()
+	if err := jwks.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// kid が空でも鍵が1つなら使う
+	if _, err := jwks.Key(ctx, ""); err != nil {
+		t.Errorf("Key(\"\") = %v, want the only key", err)
+	}
+
+	// 鍵のローテーション後、未知の kid は取得し直して探す
+	server.set(jwksBody(t, rsaJWK("old", &rsaKey.PublicKey), ecJWK("new", &ecKey.PublicKey)))
+	jwks.mu.Lock()
+	jwks.fetchedAt = time.Now().Add(-jwksMinRefreshInterval)
+	jwks.mu.Unlock()
+	key, err := jwks.Key(ctx, "new")
+	if err != nil {
+		t.Fatalf("Key(new) = %v, want it found after a refresh", err)
+	}
+	if pub, ok := key.(*ecdsa.PublicKey); !ok || !pub.Equal(&ecKey.PublicKey) {
+		t.Errorf("Key(new) = %#v, want the EC key", key)
+	}
+	if n := server.count(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+
+	// 鍵が複数あると kid が空のトークンには使えない
+	if _, err := jwks.Key(ctx, ""); err != ErrUnknownKey {
+		t.Errorf("Key(\"\") with two keys = %v, want ErrUnknownKey", err)
+	}
+
+	// 直前に取得したばかりなら、未知の kid でも取得し直さない
+	if _, err := jwks.Key(ctx, "missing"); err != ErrUnknownKey {
+		t.Errorf("Key(missing) = %v, want ErrUnknownKey", err)
+	}
+	if n := server.count(); n != 2 {
+		t.Errorf("requests = %d, want no refresh within %s", n, jwksMinRefreshInterval)
+	}
+}
+
+func TestAuthJWT(t *testing.T) {
+	rsaKey, ecKey := testKeys(t)
+	server := newJWKSServer(t, jwksBody(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey)))
+	verifier := &JWTVerifier{
+		JWKS:     NewJWKS(server.URL),
+		Audience: "authenticated",
+		Issuer:   "https://example.supabase.co/auth/v1",
+	}
+
+	r := gin.New()
+	r.GET("/", Auth(verifier, nil), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id")+" "+c.GetString("auth_method"))
+	})
+
+	now := time.Now()
+	claims := func(modify func(*SupabaseJWTClaims)) *SupabaseJWTClaims {
+		c := &SupabaseJWTClaims{
+			Sub:   "11111111-1111-4111-8111-111111111111",
+			Email: "user@example.com",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Audience:  jwt.ClaimStrings{"authenticated"},
+				Issuer:    "https://example.supabase.co/auth/v1",
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+				IssuedAt:  jwt.NewNumericDate(now),
+			},
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, kid string, c *SupabaseJWTClaims, key interface{}) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"RS256", sign(jwt.SigningMethodRS256, "rsa", claims(nil), rsaKey), http.StatusOK},
+		{"ES256", sign(jwt.SigningMethodES256, "ec", claims(nil), ecKey), http.StatusOK},
+		{"expired", sign(jwt.SigningMethodRS256, "rsa", claims(func(c *SupabaseJWTClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Hour))
+		}), rsaKey), http.StatusUnauthorized},
+		{"expired within leeway", sign(jwt.SigningMethodRS256, "rsa", claims(func(c *SupabaseJWTClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-jwtLeeway / 2))
+		}), rsaKey), http.StatusOK},
+		{"without exp", sign(jwt.SigningMethodRS256, "rsa", claims(func(c *SupabaseJWTClaims) {
+			c.ExpiresAt = nil
+		}), rsaKey), http.StatusUnauthorized},
+		{"wrong aud", sign(jwt.SigningMethodRS256, "rsa", claims(func(c *SupabaseJWTClaims) {
+			c.Audience = jwt.ClaimStrings{"anon"}
+		}), rsaKey), http.StatusUnauthorized},
+		{"wrong iss", sign(jwt.SigningMethodRS256, "rsa", claims(func(c *SupabaseJWTClaims) {
+			c.Issuer = "https://other.supabase.co/auth/v1"
+		}), rsaKey), http.StatusUnauthorized},
+		{"without sub", sign(jwt.SigningMethodRS256, "rsa", claims(func(c *SupabaseJWTClaims) {
+			c.Sub = ""
+		}), rsaKey), http.StatusUnauthorized},
+		{"ES256 with the kid of an RSA key", sign(jwt.SigningMethodES256, "rsa", claims(nil), ecKey), http.StatusUnauthorized},
+		{"HS256 without a secret", sign(jwt.SigningMethodHS256, "rsa", claims(nil), []byte("secret")), http.StatusUnauthorized},
+		{"unknown kid", sign(jwt.SigningMethodRS256, "missing", claims(nil), rsaKey), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body: %s)", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status == http.StatusOK && w.Body.String() != "11111111-1111-4111-8111-111111111111 "+AuthMethodSession {
+				t.Errorf("body = %q, want the token's user as a session", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"container/list"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig 1分あたりのリクエスト数（0以下なら制限しない）
+// 参照系（GET / HEAD / OPTIONS）と変更系で別々に数える
+type RateLimitConfig struct {
+	ReadsPerMinute  int
+	WritesPerMinute int
+	MaxKeys         int // 保持するキーの上限（超えたら最も長く使われていないキーから破棄）
+}
+
+// defaultRateLimitMaxKeys MaxKeys が未設定の場合の上限
+const defaultRateLimitMaxKeys = 10000
+
+// KeyedLimiter キー（ユーザーまたはIPアドレス）ごとのトークンバケット
+// 1分あたり perMinute 回まで補充され、最大 perMinute 回まで連続で使える
+type KeyedLimiter struct {
+	perMinute int
+	maxKeys   int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // 先頭が最近使われたキー
+}
+
+type limiterEntry struct {
+	key     string
+	limiter *rate.Limiter
+}
+
+func NewKeyedLimiter(perMinute, maxKeys int) *KeyedLimiter {
+	if maxKeys <= 0 {
+		maxKeys = defaultRateLimitMaxKeys
+	}
+	return &KeyedLimiter{
+		perMinute: perMinute,
+		maxKeys:   maxKeys,
+		entries:   map[string]*list.Element{},
+		lru:       list.New(),
+	}
+}
+
+// rateLimitResult 1回の判定結果（レスポンスヘッダー用）
+type rateLimitResult struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Duration // 上限まで回復するまでの時間
+	retryAfter time.Duration // 次に1回使えるようになるまでの時間
+}
+
+// Allow key のリクエストを1回数え、許可するかどうかを返す
+func (l *KeyedLimiter) Allow(key string, now time.Time) rateLimitResult {
+	limiter := l.limiter(key)
+	allowed := limiter.AllowN(now, 1)
+
+	tokens := limiter.TokensAt(now)
+	perSecond := float64(l.perMinute) / 60
+	result := rateLimitResult{
+		allowed:   allowed,
+		limit:     l.perMinute,
+		remaining: int(math.Max(0, math.Floor(tokens))),
+		reset:     time.Duration((float64(l.perMinute) - tokens) / perSecond * float64(time.Second)),
+	}
+	if !allowed {
+		result.retryAfter = time.Duration((1 - tokens) / perSecond * float64(time.Second))
+	}
+	return result
+}
+
+// limiter key のトークンバケットを取得（無ければ作成し、上限を超えたら古いキーを破棄）
+func (l *KeyedLimiter) limiter(key string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		l.lru.MoveToFront(elem)
+		return elem.Value.(*limiterEntry).limiter
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(float64(l.perMinute)/60), l.perMinute)
+	l.entries[key] = l.lru.PushFront(&limiterEntry{key: key, limiter: limiter})
+	for l.lru.Len() > l.maxKeys {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.entries, oldest.Value.(*limiterEntry).key)
+	}
+	return limiter
+}
+
+// RateLimit 認証済みならユーザー（user_id）、未認証ならクライアントのIPアドレスごとにリクエスト数を制限する
+// 認証が必要なルートでは Auth の後に使う。RateLimit-Limit / RateLimit-Remaining / RateLimit-Reset を付け、
+// 超過時は 429 と Retry-After を返す
+func RateLimit(config RateLimitConfig) gin.HandlerFunc {
+	var reads, writes *KeyedLimiter
+	if config.ReadsPerMinute > 0 {
+		reads = NewKeyedLimiter(config.ReadsPerMinute, config.MaxKeys)
+	}
+	if config.WritesPerMinute > 0 {
+		writes = NewKeyedLimiter(config.WritesPerMinute, config.MaxKeys)
+	}
+
+	return func(c *gin.Context) {
+		limiter := writes
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			limiter = reads
+		}
+
+		key := "ip:" + c.ClientIP()
+		if userID := c.GetString("user_id"); userID != "" {
+			key = "user:" + userID
+		}
+		limit(c, limiter, key)
+	}
+}
+
+// IPRateLimit 認証の前にクライアントのIPアドレスごとにリクエスト数を制限する（perMinute が0以下なら制限しない）
+// 不正なJWT・推測した API トークンによるリクエストも数えるため、Auth より前に使う
+// ヘッダーと超過時のレスポンスは RateLimit と同じ
+func IPRateLimit(perMinute, maxKeys int) gin.HandlerFunc {
+	var limiter *KeyedLimiter
+	if perMinute > 0 {
+		limiter = NewKeyedLimiter(perMinute, maxKeys)
+	}
+	return func(c *gin.Context) {
+		limit(c, limiter, c.ClientIP())
+	}
+}
+
+// limit key のリクエストを数えてヘッダーを付け、超過していれば 429 で中断する（limiter が nil なら何もしない）
+func limit(c *gin.Context, limiter *KeyedLimiter, key string) {
+	if limiter == nil {
+		c.Next()
+		return
+	}
+
+	result := limiter.Allow(key, time.Now())
+	c.Header("RateLimit-Limit", strconv.Itoa(result.limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.reset)))
+	if !result.allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.retryAfter)))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// ceilSeconds 秒単位に切り上げ（0未満は0）
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestKeyedLimiterPerKeyBuckets(t *testing.T) {
+	l := NewKeyedLimiter(3, 10)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if r := l.Allow("a", now); !r.allowed || r.remaining != 2-i {
+			t.Fatalf("request %d for a = %+v, want allowed with %d remaining", i, r, 2-i)
+		}
+	}
+	r := l.Allow("a", now)
+	if r.allowed || r.remaining != 0 || r.retryAfter <= 0 {
+		t.Fatalf("4th request for a = %+v, want denied with a retry-after", r)
+	}
+
+	// 他のキーは別のバケット
+	if r := l.Allow("b", now); !r.allowed || r.remaining != 2 {
+		t.Errorf("first request for b = %+v, want allowed with 2 remaining", r)
+	}
+
+	// 1分あたり3回なので20秒で1回分回復する
+	if r := l.Allow("a", now.Add(20*time.Second)); !r.allowed {
+		t.Errorf("request for a after 20s = %+v, want allowed", r)
+	}
+}
+
+func TestKeyedLimiterEvictsLeastRecentlyUsed(t *testing.T) {
+	l := NewKeyedLimiter(1, 2)
+	now := time.Now()
+
+	l.Allow("a", now)
+	l.Allow("b", now)
+	l.Allow("a", now) // a を最近使ったものにする
+	l.Allow("c", now) // b が破棄される
+
+	if len(l.entries) != 2 || l.lru.Len() != 2 {
+		t.Fatalf("keys = %d (lru %d), want 2", len(l.entries), l.lru.Len())
+	}
+	if _, ok := l.entries["b"]; ok {
+		t.Errorf("b was kept, want it evicted as the least recently used key")
+	}
+	if r := l.Allow("a", now); r.allowed {
+		t.Errorf("a was reset, want its exhausted bucket kept")
+	}
+	// 破棄されたキーは新しいバケットで始まる
+	if r := l.Allow("b", now); !r.allowed {
+		t.Errorf("b after eviction = %+v, want a fresh bucket", r)
+	}
+}
+
+func newRateLimitRouter(handlers ...gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	handlers = append(handlers, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.Any("/", handlers...)
+	return r
+}
+
+func serve(r http.Handler, method, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	r := newRateLimitRouter(RateLimit(RateLimitConfig{ReadsPerMinute: 2, WritesPerMinute: 1}))
+
+	w := serve(r, http.MethodGet, "192.0.2.1:1234")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("RateLimit-Limit = %q, want 2", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "1" {
+		t.Errorf("RateLimit-Remaining = %q, want 1", got)
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "30" {
+		t.Errorf("RateLimit-Reset = %q, want 30", got)
+	}
+
+	serve(r, http.MethodGet, "192.0.2.1:1234")
+	w = serve(r, http.MethodGet, "192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+	if retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || retryAfter < 1 || retryAfter > 30 {
+		t.Errorf("Retry-After = %q, want 1-30 seconds", w.Header().Get("Retry-After"))
+	}
+
+	// 変更系は参照系とは別に数える
+	if w := serve(r, http.MethodPost, "192.0.2.1:1234"); w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("POST = %d (limit %q), want allowed with the write budget", w.Code, w.Header().Get("RateLimit-Limit"))
+	}
+	// 他のIPアドレスは別に数える
+	if w := serve(r, http.MethodGet, "192.0.2.2:1234"); w.Code != http.StatusNoContent {
+		t.Errorf("GET from another IP = %d, want allowed", w.Code)
+	}
+}
+
+func TestRateLimitKeysByUser(t *testing.T) {
+	setUser := func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set("user_id", user)
+		}
+	}
+	r := newRateLimitRouter(setUser, RateLimit(RateLimitConfig{ReadsPerMinute: 1}))
+
+	request := func(user, remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request("u1", "192.0.2.1:1"); code != http.StatusNoContent {
+		t.Fatalf("first request = %d", code)
+	}
+	// 同じユーザーはIPアドレスが変わっても同じバケット
+	if code := request("u1", "192.0.2.9:1"); code != http.StatusTooManyRequests {
+		t.Errorf("same user from another IP = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// 同じIPアドレスでも別のユーザーは別のバケット
+	if code := request("u2", "192.0.2.1:1"); code != http.StatusNoContent {
+		t.Errorf("another user from the same IP = %d, want %d", code, http.StatusNoContent)
+	}
+}
+
+func TestIPRateLimitIgnoresUntrustedForwardedFor(t *testing.T) {
+	r := newRateLimitRouter(IPRateLimit(1, 10))
+	if err := r.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request("198.51.100.1"); code != http.StatusNoContent {
+		t.Fatalf("first request = %d", code)
+	}
+	if code := request("198.51.100.2"); code != http.StatusTooManyRequests {
+		t.Errorf("spoofed X-Forwarded-For = %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	r := newRateLimitRouter(RateLimit(RateLimitConfig{}), IPRateLimit(0, 0))
+	for i := 0; i < 5; i++ {
+		w := serve(r, http.MethodGet, "192.0.2.1:1234")
+		if w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("request %d = %d (limit %q), want unlimited without headers", i, w.Code, w.Header().Get("RateLimit-Limit"))
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader リクエストIDのヘッダー（クライアント・ロードバランサーが付けたものを引き継ぐ）
+const RequestIDHeader = "X-Request-ID"
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID リクエストごとのIDをコンテキスト（request_id）とレスポンスヘッダーに設定する
+// 受け取ったIDが不正な形式の場合は新しく発行する
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err == nil {
+				id = hex.EncodeToString(b)
+			} else {
+				id = ""
+			}
+		}
+		c.Set("request_id", id)
+		if id != "" {
+			c.Header(RequestIDHeader, id)
+		}
+		c.Next()
+	}
+}
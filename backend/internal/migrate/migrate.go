@@ -0,0 +1,274 @@
+// Package migrate はバージョン番号付きの SQL マイグレーションを順に適用・取り消しする
+// 適用済みのバージョンは schema_migrations テーブルに記録する
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fileNamePattern マイグレーションのファイル名（例: 001_add_archive_fields.up.sql）
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([0-9A-Za-z_]+)\.(up|down)\.sql$`)
+
+// ErrIrreversible down ファイルが無いマイグレーションは取り消せない
+var ErrIrreversible = errors.New("migration has no down file")
+
+// Migration 1つのバージョンの SQL
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// Status マイグレーションの適用状況（AppliedAt が nil なら未適用）
+// Missing は適用済みだがこのバイナリに含まれていないバージョン
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	Missing   bool
+}
+
+// Load fsys 直下のマイグレーションをバージョン順に読み込む
+// 同じバージョンで名前が異なるファイル、同じバージョンの up / down が複数ある場合（001 と 1 など）、
+// up ファイルの無いバージョンはエラーにする（down ファイルが無いものは取り消せないマイグレーションとして読み込む）
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	files := map[string]string{} // "バージョン.up|down" → ファイル名
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid version: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("version %d is used by both %q and %q", version, m.Name, match[2])
+		}
+		key := fmt.Sprintf("%d.%s", version, match[3])
+		if other, ok := files[key]; ok {
+			return nil, fmt.Errorf("version %d has more than one %s file: %q and %q", version, match[3], other, entry.Name())
+		}
+		files[key] = entry.Name()
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, m := range byVersion {
+		if _, ok := files[fmt.Sprintf("%d.up", version)]; !ok {
+			return nil, fmt.Errorf("%s: missing up file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Runner マイグレーションの適用・取り消し
+// 複数のインスタンスが同時に起動しても1つずつ適用されるよう、各マイグレーションを
+// トランザクション内でアドバイザリーロックを取ってから実行する（PgBouncer のトランザクションプーリングでも使えるよう
+// セッションロックではなく pg_advisory_xact_lock を使う）。ロックを待っていたインスタンスは、
+// ロック取得後に適用状況を読み直すため同じマイグレーションを二重に実行しない
+type Runner struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func New(db *gorm.DB, fsys fs.FS) (*Runner, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{db: db, migrations: migrations}, nil
+}
+
+// lockKey マイグレーション用のアドバイザリーロックのキー
+func lockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte("career-schedule-api/migrate"))
+	return int64(h.Sum64())
+}
+
+// Up 未適用のマイグレーションをすべて順に適用し、適用したものを返す
+func (r *Runner) Up() ([]Migration, error) {
+	var applied []Migration
+	for {
+		var next *Migration
+		err := r.locked(func(tx *gorm.DB, versions map[int64]time.Time) error {
+			for i := range r.migrations {
+				if _, ok := versions[r.migrations[i].Version]; !ok {
+					next = &r.migrations[i]
+					break
+				}
+			}
+			if next == nil {
+				return nil
+			}
+			if err := tx.Exec(next.Up).Error; err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", next.Version, next.Name, time.Now()).Error
+		})
+		if err != nil {
+			if next != nil {
+				return applied, fmt.Errorf("migration %s: %w", next, err)
+			}
+			return applied, err
+		}
+		if next == nil {
+			return applied, nil
+		}
+		applied = append(applied, *next)
+	}
+}
+
+// Down 適用済みのマイグレーションを新しいものから steps 件取り消し、取り消したものを返す
+func (r *Runner) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	for len(reverted) < steps {
+		var last *Migration
+		err := r.locked(func(tx *gorm.DB, versions map[int64]time.Time) error {
+			var latest int64 = -1
+			for version := range versions {
+				if version > latest {
+					latest = version
+				}
+			}
+			if latest < 0 {
+				return nil
+			}
+			for i := range r.migrations {
+				if r.migrations[i].Version == latest {
+					last = &r.migrations[i]
+				}
+			}
+			if last == nil {
+				return fmt.Errorf("applied version %d is not included in this build", latest)
+			}
+			if last.Down == "" {
+				return ErrIrreversible
+			}
+			if err := tx.Exec(last.Down).Error; err != nil {
+				return err
+			}
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", last.Version).Error
+		})
+		if err != nil {
+			if last != nil {
+				return reverted, fmt.Errorf("migration %s: %w", last, err)
+			}
+			return reverted, err
+		}
+		if last == nil {
+			return reverted, nil
+		}
+		reverted = append(reverted, *last)
+	}
+	return reverted, nil
+}
+
+// Status すべてのマイグレーションの適用状況（バージョン順）
+func (r *Runner) Status() ([]Status, error) {
+	var exists bool
+	if err := r.db.Raw("SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists).Error; err != nil {
+		return nil, err
+	}
+	versions := map[int64]time.Time{}
+	if exists {
+		var err error
+		if versions, err = appliedVersions(r.db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	known := map[int64]bool{}
+	for _, m := range r.migrations {
+		known[m.Version] = true
+		status := Status{Migration: m}
+		if appliedAt, ok := versions[m.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, appliedAt := range versions {
+		if !known[version] {
+			appliedAt := appliedAt
+			statuses = append(statuses, Status{Migration: Migration{Version: version}, AppliedAt: &appliedAt, Missing: true})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// locked ロックを取ったトランザクション内で、適用済みのバージョンを渡して fn を実行する
+// schema_migrations の作成もロックの内側で行う（同時に作成しようとして失敗しないように）
+func (r *Runner) locked(fn func(tx *gorm.DB, versions map[int64]time.Time) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey()).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)`).Error; err != nil {
+			return err
+		}
+		versions, err := appliedVersions(tx)
+		if err != nil {
+			return err
+		}
+		return fn(tx, versions)
+	})
+}
+
+func appliedVersions(db *gorm.DB) (map[int64]time.Time, error) {
+	var rows []struct {
+		Version   int64
+		AppliedAt time.Time
+	}
+	if err := db.Raw("SELECT version, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	versions := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row.AppliedAt
+	}
+	return versions, nil
+}
@@ -0,0 +1,104 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"career-schedule-api/migrations"
+)
+
+func file(body string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(body)}
+}
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_tenth.up.sql":   file("CREATE TABLE tenth ();"),
+		"010_tenth.down.sql": file("DROP TABLE tenth;"),
+		"002_second.up.sql":  file("CREATE TABLE second ();"),
+		"001_first.up.sql":   file("CREATE TABLE first ();"),
+		"001_first.down.sql": file("DROP TABLE first;"),
+		"README.md":          file("ignored"),
+		"003_draft.sql":      file("ignored"),
+	}
+
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := []string{"001_first", "002_second", "010_tenth"}
+	if len(got) != len(want) {
+		t.Fatalf("migrations = %v, want %v", got, want)
+	}
+	for i, m := range got {
+		if m.String() != want[i] {
+			t.Errorf("migrations[%d] = %s, want %s", i, m, want[i])
+		}
+	}
+	if got[0].Up != "CREATE TABLE first ();" || got[0].Down != "DROP TABLE first;" {
+		t.Errorf("001 = %+v, want its up and down bodies", got[0])
+	}
+}
+
+func TestLoadWithoutDownFile(t *testing.T) {
+	got, err := Load(fstest.MapFS{"001_first.up.sql": file("SELECT 1;")})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	// down ファイルが無いものは読み込めるが、取り消せない（Runner.Down が ErrIrreversible を返す）
+	if len(got) != 1 || got[0].Down != "" {
+		t.Errorf("migrations = %+v, want one without a down body", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{
+			"missing up file",
+			fstest.MapFS{"001_first.down.sql": file("DROP TABLE first;")},
+			"missing up file",
+		},
+		{
+			"same version with different names",
+			fstest.MapFS{"001_first.up.sql": file("SELECT 1;"), "001_other.up.sql": file("SELECT 2;")},
+			"is used by both",
+		},
+		{
+			"same version written differently",
+			fstest.MapFS{"001_first.up.sql": file("SELECT 1;"), "1_first.up.sql": file("SELECT 2;")},
+			"more than one up file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// 埋め込んだマイグレーションはすべて読み込め、取り消せる
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range got {
+		if m.Version != int64(i) {
+			t.Errorf("migrations[%d] = %s, want version %d (no gaps)", i, m, i)
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("%s has no down file", m)
+		}
+	}
+}
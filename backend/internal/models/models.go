@@ -8,17 +8,19 @@ import (
 )
 
 type Company struct {
-	ID           string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID       string     `json:"user_id" gorm:"type:uuid;not null;index"`
-	Name         string     `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
-	Industry     string     `json:"industry" validate:"max=50"`
-	Position     string     `json:"position" validate:"max=100"`
-	CurrentStage string     `json:"current_stage" gorm:"column:current_stage;not null" validate:"required,oneof=entry document_review first_interview second_interview final_interview offer rejected"`
-	Notes        string     `json:"notes" validate:"max=1000"`
-	IsArchived   bool       `json:"is_archived" gorm:"default:false;index"`
-	ArchivedAt   *time.Time `json:"archived_at"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID           string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       string         `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name         string         `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
+	Industry     string         `json:"industry" validate:"max=50"`
+	Position     string         `json:"position" validate:"max=100"`
+	CurrentStage string         `json:"current_stage" gorm:"column:current_stage;not null" validate:"required,oneof=entry document_review first_interview second_interview final_interview offer rejected"`
+	Notes        string         `json:"notes" validate:"max=1000"`
+	IsArchived   bool           `json:"is_archived" gorm:"default:false;index"`
+	ArchivedAt   *time.Time     `json:"archived_at"`
+	AutoArchived bool           `json:"auto_archived" gorm:"not null;default:false"` // 不合格による自動アーカイブ（選考の再開時に解除する）
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"` // ゴミ箱に移動した日時（保持期間を過ぎると完全に削除）
 }
 
 type Event struct {
@@ -33,13 +35,249 @@ type Event struct {
 	ConfirmedSlot     datatypes.JSON `json:"confirmed_slot" gorm:"column:confirmed_slot;type:jsonb"`
 	InterviewDuration int            `json:"interview_duration" gorm:"column:interview_duration;default:30" validate:"min=15,max=300"`
 	CustomEmailFormat string         `json:"custom_email_format" gorm:"column:custom_email_format" validate:"max=2000"`
+	EmailTemplateID   *string        `json:"email_template_id" gorm:"column:email_template_id;type:uuid;index" validate:"omitempty,uuid"`
 	Location          string         `json:"location" validate:"max=200"`
 	IsOnline          bool           `json:"is_online" gorm:"column:is_online;default:false"`
 	Notes             string         `json:"notes" validate:"max=1000"`
 	IsArchived        bool           `json:"is_archived" gorm:"default:false;index"`
 	ArchivedAt        *time.Time     `json:"archived_at"`
+	Sequence          int            `json:"sequence" gorm:"not null;default:0"` // iCalendar の SEQUENCE（更新ごとに加算）
 	CreatedAt         time.Time      `json:"created_at"`
 	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"deleted_at" gorm:"index"` // ゴミ箱に移動した日時（保持期間を過ぎると完全に削除）
+}
+
+// ConfirmedStartExpr 予定の確定日時の開始を表す SQL 式（confirmed_slot の JSONB から timestamptz へ変換）
+const ConfirmedStartExpr = "(confirmed_slot->>'start_time')::timestamptz"
+
+// CompanyStageTransition 企業の選考ステージ変更履歴（FromStage が空なら登録時の初期ステージ）
+type CompanyStageTransition struct {
+	ID             string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	CompanyID      string    `json:"company_id" gorm:"column:company_id;type:uuid;not null;index"`
+	UserID         string    `json:"user_id" gorm:"column:user_id;type:uuid;not null;index"`
+	FromStage      string    `json:"from_stage" gorm:"column:from_stage"`
+	ToStage        string    `json:"to_stage" gorm:"column:to_stage;not null"`
+	Note           string    `json:"note" validate:"max=1000"`
+	TransitionedAt time.Time `json:"transitioned_at" gorm:"column:transitioned_at;not null;index"`
+}
+
+// EmailTemplate ユーザーごとの再利用可能なメールテンプレート
+// EventType が空のものはユーザー全体のデフォルト候補、設定されたものはその種別の予定向け
+type EmailTemplate struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name      string    `json:"name" gorm:"not null" validate:"required,min=1,max=100"`
+	Body      string    `json:"body" gorm:"not null" validate:"required,max=2000"`
+	EventType string    `json:"event_type" gorm:"column:event_type" validate:"omitempty,oneof=meeting interview info_session group_discussion final_interview"`
+	IsDefault bool      `json:"is_default" gorm:"column:is_default;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JobRun のステータス
+const (
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+// JobRun バックグラウンドジョブの実行記録
+type JobRun struct {
+	ID           string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	JobName      string     `json:"job_name" gorm:"column:job_name;not null;index"`
+	Status       string     `json:"status" gorm:"not null"`
+	StartedAt    time.Time  `json:"started_at" gorm:"column:started_at;not null;index"`
+	FinishedAt   *time.Time `json:"finished_at" gorm:"column:finished_at"`
+	RowsAffected int64      `json:"rows_affected" gorm:"column:rows_affected;not null;default:0"`
+	Error        string     `json:"error"`
+}
+
+// Reminder の通知チャネル
+const (
+	ReminderChannelLog     = "log"
+	ReminderChannelEmail   = "email"
+	ReminderChannelWebhook = "webhook"
+)
+
+// Reminder のステータス
+// sending は送信中（送信前に確定させ、送信結果を記録できずに停止した場合もこの状態で残して再送しない）
+const (
+	ReminderPending = "pending"
+	ReminderSending = "sending"
+	ReminderSent    = "sent"
+	ReminderFailed  = "failed"
+)
+
+// Reminder 確定済み予定の開始前に送る通知（RemindAt = 開始日時 - OffsetMinutes）
+type Reminder struct {
+	ID            string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EventID       string     `json:"event_id" gorm:"column:event_id;type:uuid;not null;index"`
+	UserID        string     `json:"user_id" gorm:"column:user_id;type:uuid;not null;index"`
+	OffsetMinutes int        `json:"offset_minutes" gorm:"column:offset_minutes;not null" validate:"min=0,max=10080"`
+	Channel       string     `json:"channel" gorm:"not null" validate:"required,oneof=log email webhook"`
+	Recipient     string     `json:"recipient"` // email チャネルの宛先
+	RemindAt      time.Time  `json:"remind_at" gorm:"column:remind_at;not null"`
+	Status        string     `json:"status" gorm:"not null;index:idx_reminders_due,priority:1"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"column:next_attempt_at;not null;index:idx_reminders_due,priority:2"`
+	SentAt        *time.Time `json:"sent_at" gorm:"column:sent_at"`
+	LastError     string     `json:"last_error" gorm:"column:last_error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ReminderDelivery リマインダーの送信記録（試行ごとに1行）
+type ReminderDelivery struct {
+	ID         string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ReminderID string    `json:"reminder_id" gorm:"column:reminder_id;type:uuid;not null;index"`
+	Channel    string    `json:"channel" gorm:"not null"`
+	Attempt    int       `json:"attempt" gorm:"not null"`
+	Status     string    `json:"status" gorm:"not null"` // sent | failed
+	Error      string    `json:"error"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Webhook ユーザーが登録した送信先。購読している種別の変更があると署名付きで POST する
+type Webhook struct {
+	ID          string                      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      string                      `json:"user_id" gorm:"type:uuid;not null;index"`
+	URL         string                      `json:"url" gorm:"not null" validate:"required,url,max=500"`
+	Secret      string                      `json:"-" gorm:"not null"` // HMAC-SHA256 署名の鍵（作成・再発行時のみ返す）
+	Events      datatypes.JSONSlice[string] `json:"events" gorm:"type:jsonb;not null" validate:"required,min=1,dive,required"`
+	Description string                      `json:"description" validate:"max=200"`
+	IsActive    bool                        `json:"is_active" gorm:"column:is_active;not null;default:true"`
+	CreatedAt   time.Time                   `json:"created_at"`
+	UpdatedAt   time.Time                   `json:"updated_at"`
+}
+
+// WebhookDelivery のステータス
+// sending は送信中（確保した実行が next_attempt_at までに結果を記録できなかった場合は再送する）
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySending   = "sending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery Webhook の送信待ち・送信履歴（アウトボックス）
+// 変更と同じトランザクションで積み、バックグラウンドで少なくとも1回届ける
+type WebhookDelivery struct {
+	ID             string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WebhookID      string         `json:"webhook_id" gorm:"column:webhook_id;type:uuid;not null;index"`
+	UserID         string         `json:"user_id" gorm:"column:user_id;type:uuid;not null;index"`
+	EventKind      string         `json:"event_kind" gorm:"column:event_kind;not null"`
+	Payload        datatypes.JSON `json:"payload" gorm:"type:jsonb;not null"`
+	Status         string         `json:"status" gorm:"not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int            `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at" gorm:"column:next_attempt_at;not null;index:idx_webhook_deliveries_due,priority:2"`
+	LastStatusCode int            `json:"last_status_code" gorm:"column:last_status_code"`
+	LastResponse   string         `json:"last_response" gorm:"column:last_response"` // レスポンス本文の先頭（デバッグ用）
+	LastError      string         `json:"last_error" gorm:"column:last_error"`
+	DeliveredAt    *time.Time     `json:"delivered_at" gorm:"column:delivered_at"`
+	CreatedAt      time.Time      `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// AuditLog 変更操作の記録（追記のみ。更新・削除はデータベースのトリガーで禁止している）
+// Changes は変更された項目ごとの {"before": ..., "after": ...}
+type AuditLog struct {
+	ID         string         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     string         `json:"user_id" gorm:"column:user_id;type:uuid;not null;index:idx_audit_logs_user_created,priority:1"`
+	EntityType string         `json:"entity_type" gorm:"column:entity_type;not null"`
+	EntityID   string         `json:"entity_id" gorm:"column:entity_id;not null;index"`
+	Action     string         `json:"action" gorm:"not null"`
+	Changes    datatypes.JSON `json:"changes" gorm:"type:jsonb;not null"`
+	RequestID  string         `json:"request_id" gorm:"column:request_id"`
+	ClientIP   string         `json:"client_ip" gorm:"column:client_ip"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"not null;index:idx_audit_logs_user_created,priority:2"`
+}
+
+// ユーザー設定のデフォルト値
+const (
+	// DefaultConflictBufferMinutes 予定の前後に確保する移動・準備時間（分）
+	DefaultConflictBufferMinutes = 30
+	// DefaultTimezone 設定が無いユーザーのタイムゾーン
+	DefaultTimezone = "Asia/Tokyo"
+	// DefaultInterviewDuration 予定作成時に所要時間が指定されなかった場合の値（分）
+	DefaultInterviewDuration = 30
+	// DefaultAutoArchiveDelayDays 確定・キャンセル済みの予定を何日後にアーカイブするか
+	DefaultAutoArchiveDelayDays = 1
+	DefaultWorkingHoursStart    = "09:00"
+	DefaultWorkingHoursEnd      = "18:00"
+	// DefaultDigestHour 日次ダイジェストを送る時刻（ユーザーのタイムゾーンの時）
+	DefaultDigestHour = 7
+)
+
+// UserSettings ユーザーごとの設定（JWTの sub をキーとする）
+type UserSettings struct {
+	UserID                       string     `json:"user_id" gorm:"type:uuid;primary_key"`
+	ConflictBufferMinutes        int        `json:"conflict_buffer_minutes" gorm:"column:conflict_buffer_minutes;not null;default:30" validate:"min=0,max=240"`
+	Timezone                     string     `json:"timezone" gorm:"not null;default:'Asia/Tokyo'" validate:"required,max=64"` // IANA タイムゾーン名
+	DefaultInterviewDuration     int        `json:"default_interview_duration" gorm:"column:default_interview_duration;not null;default:30" validate:"min=15,max=300"`
+	AutoArchiveRejectedCompanies bool       `json:"auto_archive_rejected_companies" gorm:"column:auto_archive_rejected_companies;not null;default:true"`
+	AutoArchiveEvents            bool       `json:"auto_archive_events" gorm:"column:auto_archive_events;not null;default:true"`
+	AutoArchiveDelayDays         int        `json:"auto_archive_delay_days" gorm:"column:auto_archive_delay_days;not null;default:1" validate:"min=1,max=90"`
+	WorkingHoursStart            string     `json:"working_hours_start" gorm:"column:working_hours_start;not null;default:'09:00'" validate:"required,len=5"` // HH:MM
+	WorkingHoursEnd              string     `json:"working_hours_end" gorm:"column:working_hours_end;not null;default:'18:00'" validate:"required,len=5"`     // HH:MM
+	DigestEnabled                bool       `json:"digest_enabled" gorm:"column:digest_enabled;not null;default:false"`
+	DigestEmail                  string     `json:"digest_email" gorm:"column:digest_email" validate:"omitempty,email,max=254"`
+	DigestHour                   int        `json:"digest_hour" gorm:"column:digest_hour;not null;default:7" validate:"min=0,max=23"`
+	LastDigestAt                 *time.Time `json:"last_digest_at" gorm:"column:last_digest_at"` // 最後に日次ダイジェストを処理した日時
+	CreatedAt                    time.Time  `json:"created_at"`
+	UpdatedAt                    time.Time  `json:"updated_at"`
+}
+
+// CalendarFeedToken カレンダー購読URL用のシークレットトークン（ハッシュ化して保存）
+type CalendarFeedToken struct {
+	ID        string    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    string    `json:"user_id" gorm:"type:uuid;not null;uniqueIndex"`
+	TokenHash string    `json:"-" gorm:"column:token_hash;not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 個人用アクセストークンの権限（write は read を含む）
+const (
+	APITokenScopeRead  = "read"
+	APITokenScopeWrite = "write"
+)
+
+// APIToken スクリプトや自動化から API を使うための個人用アクセストークン（ハッシュ化して保存）
+type APIToken struct {
+	ID         string     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     string     `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string     `json:"name" gorm:"not null" validate:"required,max=100"`
+	Prefix     string     `json:"prefix" gorm:"not null"` // 一覧で見分けるためのトークンの先頭部分
+	TokenHash  string     `json:"-" gorm:"column:token_hash;not null;uniqueIndex"`
+	Scope      string     `json:"scope" gorm:"not null" validate:"required,oneof=read write"`
+	Email      string     `json:"-" gorm:"not null;default:''"` // 発行したセッションのメールアドレス（トークンでの認証時に user_email として使う）
+	ExpiresAt  *time.Time `json:"expires_at"`                   // nil なら無期限
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewUserSettings returns the settings used when the user has not saved any
+func NewUserSettings(userID string) UserSettings {
+	return UserSettings{
+		UserID:                       userID,
+		ConflictBufferMinutes:        DefaultConflictBufferMinutes,
+		Timezone:                     DefaultTimezone,
+		DefaultInterviewDuration:     DefaultInterviewDuration,
+		AutoArchiveRejectedCompanies: true,
+		AutoArchiveEvents:            true,
+		AutoArchiveDelayDays:         DefaultAutoArchiveDelayDays,
+		WorkingHoursStart:            DefaultWorkingHoursStart,
+		WorkingHoursEnd:              DefaultWorkingHoursEnd,
+		DigestHour:                   DefaultDigestHour,
+	}
+}
+
+// Location returns the user's time zone, falling back to JST when it cannot be loaded
+func (s UserSettings) Location() *time.Location {
+	if s.Timezone != "" && s.Timezone != "Local" {
+		if loc, err := time.LoadLocation(s.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.FixedZone(DefaultTimezone, 9*60*60)
 }
 
 // BeforeCreate will set the default values for the Company
@@ -62,8 +300,9 @@ func (e *Event) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// BeforeUpdate will set the updated_at field
+// BeforeUpdate will set the updated_at field and bump the iCalendar sequence
 func (e *Event) BeforeUpdate(tx *gorm.DB) error {
 	e.UpdatedAt = time.Now()
+	e.Sequence++
 	return nil
 }
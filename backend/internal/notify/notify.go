@@ -0,0 +1,33 @@
+// Package notify はリマインダーなどの通知を外部へ送るための Notifier を提供する
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+)
+
+// Message 通知1件の内容
+// To はメール通知の宛先、Payload は Webhook で送る JSON 本体（nil の場合は件名と本文を送る）
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	Payload interface{}
+}
+
+// Notifier 通知の送信先。失敗した場合はエラーを返し、呼び出し側で再送する
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// ErrNoRecipient 宛先が必要な通知で宛先が空の場合のエラー
+var ErrNoRecipient = errors.New("notify: recipient is empty")
+
+// LogNotifier 通知内容をログに出力するだけの Notifier（開発用・既定）
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, msg Message) error {
+	log.Printf("Notify: to=%q subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
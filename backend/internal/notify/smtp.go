@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTPNotifier SMTP でメールを送る Notifier
+// Username が空の場合は認証なしで送信する（ローカルの SMTP キャッチャー向け）
+type SMTPNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Addr "host:port"
+func (n SMTPNotifier) Addr() string {
+	return net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+}
+
+// BuildMessage RFC 5322 形式のメール（UTF-8、本文は base64）を組み立てる
+func (n SMTPNotifier) BuildMessage(to, subject, body string, date time.Time) []byte {
+	var b strings.Builder
+	header := func(key, value string) {
+		b.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", n.From)
+	header("To", to)
+	header("Subject", mime.BEncoding.Encode("UTF-8", subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "base64")
+	b.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return []byte(b.String())
+}
+
+func (n SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+	if n.Host == "" || n.From == "" {
+		return errors.New("notify: SMTP is not configured")
+	}
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+
+	// net/smtp は context に対応していないため、別ゴルーチンで送信してキャンセルを待つ
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(n.Addr(), auth, from.Address, []string{to.Address}, n.BuildMessage(msg.To, msg.Subject, msg.Body, time.Now()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
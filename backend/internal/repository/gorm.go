@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/jobs"
+	"career-schedule-api/internal/models"
+	"career-schedule-api/internal/webhooks"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 一覧で返す列（クエリ最適化: 必要なフィールドのみ選択、インデックス活用）
+const (
+	companyColumns = "id, user_id, name, industry, position, current_stage, notes, is_archived, archived_at, auto_archived, created_at, updated_at"
+	eventColumns   = "id, company_id, user_id, company_name, title, type, status, candidate_slots, confirmed_slot, interview_duration, custom_email_format, email_template_id, location, is_online, notes, is_archived, archived_at, sequence, created_at, updated_at"
+)
+
+// GormStore PostgreSQL（GORM）による Store
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Companies() CompanyRepository { return gormCompanies{s.db} }
+func (s *GormStore) Events() EventRepository      { return gormEvents{s.db} }
+func (s *GormStore) Recorder() Recorder           { return gormRecorder{s.db} }
+
+func (s *GormStore) UserSettings(userID string) (models.UserSettings, error) {
+	var settings models.UserSettings
+	if err := s.db.Where("user_id = ?", userID).First(&settings).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.NewUserSettings(userID), nil
+		}
+		return settings, err
+	}
+	return settings, nil
+}
+
+func (s *GormStore) CheckEmailTemplate(userID, templateID string) error {
+	var count int64
+	if err := s.db.Model(&models.EmailTemplate{}).Where("id = ? AND user_id = ?", templateID, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *GormStore) Transaction(fn func(tx Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&GormStore{db: tx})
+	})
+}
+
+// notFound gorm.ErrRecordNotFound を ErrNotFound にする
+func notFound(err error) error {
+	if err == gorm.ErrRecordNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormCompanies struct {
+	db *gorm.DB
+}
+
+func (r gormCompanies) query(userID string, filter CompanyFilter) *gorm.DB {
+	query := r.db.Model(&models.Company{}).Where("user_id = ?", userID)
+	if filter.IsArchived != nil {
+		query = query.Where("is_archived = ?", *filter.IsArchived)
+	}
+	if filter.CurrentStage != "" {
+		query = query.Where("current_stage = ?", filter.CurrentStage)
+	}
+	if filter.Industry != "" {
+		query = query.Where("industry = ?", filter.Industry)
+	}
+	return query
+}
+
+func (r gormCompanies) List(userID string, filter CompanyFilter, page Page) ([]models.Company, error) {
+	query, err := page.apply(r.query(userID, filter))
+	if err != nil {
+		return nil, err
+	}
+	var companies []models.Company
+	if err := query.Select(companyColumns).Find(&companies).Error; err != nil {
+		return nil, err
+	}
+	return companies, nil
+}
+
+func (r gormCompanies) Count(userID string, filter CompanyFilter) (int64, error) {
+	var total int64
+	err := r.query(userID, filter).Count(&total).Error
+	return total, err
+}
+
+func (r gormCompanies) Get(userID, id string) (models.Company, error) {
+	var company models.Company
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&company).Error
+	return company, notFound(err)
+}
+
+func (r gormCompanies) Create(company *models.Company) error {
+	return r.db.Create(company).Error
+}
+
+// Save は Save() を使わない（対象の行が無い場合に INSERT ... ON CONFLICT で他ユーザーの行を上書きしうるため）
+func (r gormCompanies) Save(company *models.Company) error {
+	result := r.db.Model(company).Where("user_id = ?", company.UserID).Select("*").Updates(company)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r gormCompanies) Delete(userID, id string) (models.Company, error) {
+	var company models.Company
+	result := r.db.Clauses(clause.Returning{}).Where("id = ? AND user_id = ?", id, userID).Delete(&company)
+	if result.Error != nil {
+		return company, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return company, ErrNotFound
+	}
+	return company, nil
+}
+
+type gormEvents struct {
+	db *gorm.DB
+}
+
+func (r gormEvents) query(userID string, filter EventFilter) *gorm.DB {
+	query := r.db.Model(&models.Event{}).Where("user_id = ?", userID)
+	if filter.IsArchived != nil {
+		query = query.Where("is_archived = ?", *filter.IsArchived)
+	}
+	if filter.ArchivedAt != nil {
+		query = query.Where("archived_at = ?", *filter.ArchivedAt)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.CompanyID != "" {
+		query = query.Where("company_id = ?", filter.CompanyID)
+	}
+	if filter.From != nil {
+		query = query.Where("(confirmed_slot->>'start_time') IS NOT NULL AND "+models.ConfirmedStartExpr+" >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("(confirmed_slot->>'start_time') IS NOT NULL AND "+models.ConfirmedStartExpr+" < ?", *filter.To)
+	}
+	return query
+}
+
+func (r gormEvents) List(userID string, filter EventFilter, page Page) ([]models.Event, error) {
+	query, err := page.apply(r.query(userID, filter))
+	if err != nil {
+		return nil, err
+	}
+	var events []models.Event
+	if err := query.Select(eventColumns).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r gormEvents) Count(userID string, filter EventFilter) (int64, error) {
+	var total int64
+	err := r.query(userID, filter).Count(&total).Error
+	return total, err
+}
+
+func (r gormEvents) Get(userID, id string) (models.Event, error) {
+	var event models.Event
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&event).Error
+	return event, notFound(err)
+}
+
+func (r gormEvents) Create(event *models.Event) error {
+	return r.db.Create(event).Error
+}
+
+// Save は Save() を使わない（gormCompanies.Save を参照）
+func (r gormEvents) Save(event *models.Event) error {
+	result := r.db.Model(event).Where("user_id = ?", event.UserID).Select("*").Updates(event)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r gormEvents) Delete(userID, id string) (models.Event, error) {
+	var event models.Event
+	result := r.db.Clauses(clause.Returning{}).Where("id = ? AND user_id = ?", id, userID).Delete(&event)
+	if result.Error != nil {
+		return event, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return event, ErrNotFound
+	}
+	return event, nil
+}
+
+func (r gormEvents) AutoArchive(userID string, actor audit.Actor) (int64, error) {
+	return jobs.ArchiveEvents(r.db, userID, actor)
+}
+
+type gormRecorder struct {
+	db *gorm.DB
+}
+
+func (r gormRecorder) Audit(actor audit.Actor, entityType, entityID, action string, before, after interface{}) error {
+	return audit.Record(r.db, actor, entityType, entityID, action, before, after)
+}
+
+func (r gormRecorder) Webhook(userID, kind string, data interface{}) error {
+	return webhooks.Enqueue(r.db, userID, kind, data)
+}
+
+func (r gormRecorder) StageTransition(company models.Company, from, note string) error {
+	transition := models.CompanyStageTransition{
+		CompanyID:      company.ID,
+		UserID:         company.UserID,
+		FromStage:      from,
+		ToStage:        company.CurrentStage,
+		Note:           note,
+		TransitionedAt: time.Now(),
+	}
+	return r.db.Create(&transition).Error
+}
+
+func (r gormRecorder) SyncReminders(event models.Event, defaults jobs.ReminderDefaults, recipient string) error {
+	return jobs.SyncReminders(r.db, event, defaults, recipient)
+}
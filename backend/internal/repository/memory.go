@@ -0,0 +1,572 @@
+package repository
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/jobs"
+	"career-schedule-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditEntry MemoryStore に記録された監査ログ
+type AuditEntry struct {
+	Actor      audit.Actor
+	EntityType string
+	EntityID   string
+	Action     string
+	Before     interface{}
+	After      interface{}
+}
+
+// WebhookEntry MemoryStore に記録された Webhook の送信待ち
+type WebhookEntry struct {
+	UserID string
+	Kind   string
+	Data   interface{}
+}
+
+// ReminderSync MemoryStore に記録されたリマインダーの再計算
+type ReminderSync struct {
+	Event     models.Event
+	Defaults  jobs.ReminderDefaults
+	Recipient string
+}
+
+// memoryState MemoryStore の内容（トランザクションの取り消し用に丸ごと複製できる形で持つ）
+type memoryState struct {
+	companies        map[string]models.Company
+	events           map[string]models.Event
+	settings         map[string]models.UserSettings
+	emailTemplates   map[string]string // テンプレートID → ユーザーID
+	audits           []AuditEntry
+	webhooks         []WebhookEntry
+	stageTransitions []models.CompanyStageTransition
+	reminderSyncs    []ReminderSync
+}
+
+func (s memoryState) clone() memoryState {
+	c := memoryState{
+		companies:        make(map[string]models.Company, len(s.companies)),
+		events:           make(map[string]models.Event, len(s.events)),
+		settings:         make(map[string]models.UserSettings, len(s.settings)),
+		emailTemplates:   make(map[string]string, len(s.emailTemplates)),
+		audits:           append([]AuditEntry(nil), s.audits...),
+		webhooks:         append([]WebhookEntry(nil), s.webhooks...),
+		stageTransitions: append([]models.CompanyStageTransition(nil), s.stageTransitions...),
+		reminderSyncs:    append([]ReminderSync(nil), s.reminderSyncs...),
+	}
+	for k, v := range s.companies {
+		c.companies[k] = v
+	}
+	for k, v := range s.events {
+		c.events[k] = v
+	}
+	for k, v := range s.settings {
+		c.settings[k] = v
+	}
+	for k, v := range s.emailTemplates {
+		c.emailTemplates[k] = v
+	}
+	return c
+}
+
+// MemoryStore メモリ上の Store（データベースなしでハンドラーをテストするため）
+// Transaction はエラー時に開始前の状態へ戻す。トランザクション同士は直列に実行し、入れ子にはできない
+type MemoryStore struct {
+	// Now 現在時刻（アーカイブ日時などに使う）
+	Now func() time.Time
+	// AutoArchiveDue 自動アーカイブの対象か（nil なら何もアーカイブしない）
+	// 基準は jobs.ArchiveEvents の SQL にのみあり、メモリ上では再現しない。テストで対象を指定する
+	AutoArchiveDue func(event models.Event) bool
+
+	mu    sync.Mutex
+	txMu  sync.Mutex
+	state memoryState
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		Now: time.Now,
+		state: memoryState{
+			companies:      map[string]models.Company{},
+			events:         map[string]models.Event{},
+			settings:       map[string]models.UserSettings{},
+			emailTemplates: map[string]string{},
+		},
+	}
+}
+
+func (s *MemoryStore) Companies() CompanyRepository { return memoryCompanies{s} }
+func (s *MemoryStore) Events() EventRepository      { return memoryEvents{s} }
+func (s *MemoryStore) Recorder() Recorder           { return memoryRecorder{s} }
+
+func (s *MemoryStore) UserSettings(userID string) (models.UserSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if settings, ok := s.state.settings[userID]; ok {
+		return settings, nil
+	}
+	return models.NewUserSettings(userID), nil
+}
+
+func (s *MemoryStore) CheckEmailTemplate(userID, templateID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if owner, ok := s.state.emailTemplates[templateID]; !ok || owner != userID {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MemoryStore) Transaction(fn func(tx Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	snapshot := s.state.clone()
+	s.mu.Unlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.state = snapshot
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// SetUserSettings ユーザー設定を保存する
+func (s *MemoryStore) SetUserSettings(settings models.UserSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.settings[settings.UserID] = settings
+}
+
+// AddEmailTemplate userID のメールテンプレートとして templateID を登録する
+func (s *MemoryStore) AddEmailTemplate(userID, templateID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.emailTemplates[templateID] = userID
+}
+
+// PutCompany 企業をそのまま保存する（日時などを変更しない。ID が空なら採番する）
+func (s *MemoryStore) PutCompany(company models.Company) models.Company {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if company.ID == "" {
+		company.ID = newID()
+	}
+	s.state.companies[company.ID] = company
+	return company
+}
+
+// PutEvent 予定をそのまま保存する（日時などを変更しない。ID が空なら採番する）
+func (s *MemoryStore) PutEvent(event models.Event) models.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if event.ID == "" {
+		event.ID = newID()
+	}
+	s.state.events[event.ID] = event
+	return event
+}
+
+// Company ゴミ箱のものを含めて企業を返す
+func (s *MemoryStore) Company(id string) (models.Company, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	company, ok := s.state.companies[id]
+	return company, ok
+}
+
+// Event ゴミ箱のものを含めて予定を返す
+func (s *MemoryStore) Event(id string) (models.Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.state.events[id]
+	return event, ok
+}
+
+// Audits 記録された監査ログ（記録順）
+func (s *MemoryStore) Audits() []AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]AuditEntry(nil), s.state.audits...)
+}
+
+// Webhooks 記録された Webhook の送信待ち（記録順）
+func (s *MemoryStore) Webhooks() []WebhookEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]WebhookEntry(nil), s.state.webhooks...)
+}
+
+// StageTransitions 記録された選考ステージ履歴（記録順）
+func (s *MemoryStore) StageTransitions() []models.CompanyStageTransition {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.CompanyStageTransition(nil), s.state.stageTransitions...)
+}
+
+// ReminderSyncs 記録されたリマインダーの再計算（記録順）
+func (s *MemoryStore) ReminderSyncs() []ReminderSync {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ReminderSync(nil), s.state.reminderSyncs...)
+}
+
+// newID gen_random_uuid() と同じ形式の UUID（バージョン4）
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// compareSortValues 並び替えキーの値を比較する（-1 / 0 / 1）
+func compareSortValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case time.Time:
+		bv := b.(time.Time)
+		switch {
+		case av.Before(bv):
+			return -1
+		case av.After(bv):
+			return 1
+		}
+		return 0
+	default:
+		return strings.Compare(a.(string), b.(string))
+	}
+}
+
+// paginate 並び替え・カーソル・件数を適用する（id は項目のID、value は並び替えキーの値を返す）
+func paginate[T any](items []T, page Page, id func(T) string, value func(T) interface{}) ([]T, error) {
+	if page.SortName != "" {
+		compare := func(a, b T) int {
+			if c := compareSortValues(value(a), value(b)); c != 0 {
+				return c
+			}
+			return strings.Compare(id(a), id(b))
+		}
+		sort.Slice(items, func(i, j int) bool {
+			if page.Desc {
+				return compare(items[i], items[j]) > 0
+			}
+			return compare(items[i], items[j]) < 0
+		})
+
+		if page.Cursor != nil {
+			cursorValue, err := page.cursorValue()
+			if err != nil {
+				return nil, err
+			}
+			filtered := items[:0]
+			for _, item := range items {
+				c := compareSortValues(value(item), cursorValue)
+				if c == 0 {
+					c = strings.Compare(id(item), page.Cursor.ID)
+				}
+				if (page.Desc && c < 0) || (!page.Desc && c > 0) {
+					filtered = append(filtered, item)
+				}
+			}
+			items = filtered
+		}
+	}
+	if page.Limit > 0 && len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return items, nil
+}
+
+type memoryCompanies struct {
+	s *MemoryStore
+}
+
+func (r memoryCompanies) match(company models.Company, userID string, filter CompanyFilter) bool {
+	return company.UserID == userID && !company.DeletedAt.Valid &&
+		(filter.IsArchived == nil || company.IsArchived == *filter.IsArchived) &&
+		(filter.CurrentStage == "" || company.CurrentStage == filter.CurrentStage) &&
+		(filter.Industry == "" || company.Industry == filter.Industry)
+}
+
+func (r memoryCompanies) List(userID string, filter CompanyFilter, page Page) ([]models.Company, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	companies := []models.Company{}
+	for _, company := range r.s.state.companies {
+		if r.match(company, userID, filter) {
+			companies = append(companies, company)
+		}
+	}
+	return paginate(companies, page,
+		func(c models.Company) string { return c.ID },
+		func(c models.Company) interface{} { return CompanySortValue(c, page.SortName) })
+}
+
+func (r memoryCompanies) Count(userID string, filter CompanyFilter) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var total int64
+	for _, company := range r.s.state.companies {
+		if r.match(company, userID, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r memoryCompanies) Get(userID, id string) (models.Company, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	company, ok := r.s.state.companies[id]
+	if !ok || company.UserID != userID || company.DeletedAt.Valid {
+		return models.Company{}, ErrNotFound
+	}
+	return company, nil
+}
+
+func (r memoryCompanies) Create(company *models.Company) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if company.ID == "" {
+		company.ID = newID()
+	}
+	if _, ok := r.s.state.companies[company.ID]; ok {
+		return fmt.Errorf("duplicate company id %s", company.ID)
+	}
+	if err := company.BeforeCreate(nil); err != nil {
+		return err
+	}
+	r.s.state.companies[company.ID] = *company
+	return nil
+}
+
+func (r memoryCompanies) Save(company *models.Company) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.state.companies[company.ID]
+	if !ok || existing.UserID != company.UserID || existing.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	if err := company.BeforeUpdate(nil); err != nil {
+		return err
+	}
+	r.s.state.companies[company.ID] = *company
+	return nil
+}
+
+func (r memoryCompanies) Delete(userID, id string) (models.Company, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	company, ok := r.s.state.companies[id]
+	if !ok || company.UserID != userID || company.DeletedAt.Valid {
+		return models.Company{}, ErrNotFound
+	}
+	company.DeletedAt = gorm.DeletedAt{Time: r.s.Now(), Valid: true}
+	r.s.state.companies[id] = company
+	return company, nil
+}
+
+type memoryEvents struct {
+	s *MemoryStore
+}
+
+func (r memoryEvents) match(event models.Event, userID string, filter EventFilter) bool {
+	if event.UserID != userID || event.DeletedAt.Valid {
+		return false
+	}
+	if filter.IsArchived != nil && event.IsArchived != *filter.IsArchived {
+		return false
+	}
+	if filter.ArchivedAt != nil && (event.ArchivedAt == nil || !event.ArchivedAt.Equal(*filter.ArchivedAt)) {
+		return false
+	}
+	if (filter.Status != "" && event.Status != filter.Status) ||
+		(filter.Type != "" && event.Type != filter.Type) ||
+		(filter.CompanyID != "" && event.CompanyID != filter.CompanyID) {
+		return false
+	}
+	if filter.From != nil || filter.To != nil {
+		start, ok := confirmedStart(event)
+		if !ok || (filter.From != nil && start.Before(*filter.From)) || (filter.To != nil && !start.Before(*filter.To)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r memoryEvents) List(userID string, filter EventFilter, page Page) ([]models.Event, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	events := []models.Event{}
+	for _, event := range r.s.state.events {
+		if r.match(event, userID, filter) {
+			events = append(events, event)
+		}
+	}
+	return paginate(events, page,
+		func(e models.Event) string { return e.ID },
+		func(e models.Event) interface{} { return EventSortValue(e, page.SortName) })
+}
+
+func (r memoryEvents) Count(userID string, filter EventFilter) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var total int64
+	for _, event := range r.s.state.events {
+		if r.match(event, userID, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r memoryEvents) Get(userID, id string) (models.Event, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	event, ok := r.s.state.events[id]
+	if !ok || event.UserID != userID || event.DeletedAt.Valid {
+		return models.Event{}, ErrNotFound
+	}
+	return event, nil
+}
+
+func (r memoryEvents) Create(event *models.Event) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if event.ID == "" {
+		event.ID = newID()
+	}
+	if _, ok := r.s.state.events[event.ID]; ok {
+		return fmt.Errorf("duplicate event id %s", event.ID)
+	}
+	// データベースの列の既定値
+	if event.Status == "" {
+		event.Status = "candidate"
+	}
+	if event.InterviewDuration == 0 {
+		event.InterviewDuration = models.DefaultInterviewDuration
+	}
+	if err := event.BeforeCreate(nil); err != nil {
+		return err
+	}
+	r.s.state.events[event.ID] = *event
+	return nil
+}
+
+func (r memoryEvents) Save(event *models.Event) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	existing, ok := r.s.state.events[event.ID]
+	if !ok || existing.UserID != event.UserID || existing.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	if err := event.BeforeUpdate(nil); err != nil {
+		return err
+	}
+	r.s.state.events[event.ID] = *event
+	return nil
+}
+
+func (r memoryEvents) Delete(userID, id string) (models.Event, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	event, ok := r.s.state.events[id]
+	if !ok || event.UserID != userID || event.DeletedAt.Valid {
+		return models.Event{}, ErrNotFound
+	}
+	event.DeletedAt = gorm.DeletedAt{Time: r.s.Now(), Valid: true}
+	r.s.state.events[id] = event
+	return event, nil
+}
+
+// AutoArchive userID の予定のうち AutoArchiveDue が true のものをアーカイブする
+// （データベースと同様に監査ログを記録し、Webhook は記録しない）
+func (r memoryEvents) AutoArchive(userID string, actor audit.Actor) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if r.s.AutoArchiveDue == nil {
+		return 0, nil
+	}
+	now := r.s.Now()
+	var updated int64
+	for id, event := range r.s.state.events {
+		if event.UserID != userID || !r.s.AutoArchiveDue(event) {
+			continue
+		}
+		before := event
+		archivedAt := now
+		event.IsArchived = true
+		event.ArchivedAt = &archivedAt
+		r.s.state.events[id] = event
+		r.s.state.audits = append(r.s.state.audits, AuditEntry{
+			Actor:      actor,
+			EntityType: audit.EntityEvent,
+			EntityID:   id,
+			Action:     audit.ActionArchive,
+			Before:     before,
+			After:      event,
+		})
+		updated++
+	}
+	return updated, nil
+}
+
+type memoryRecorder struct {
+	s *MemoryStore
+}
+
+func (r memoryRecorder) Audit(actor audit.Actor, entityType, entityID, action string, before, after interface{}) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.state.audits = append(r.s.state.audits, AuditEntry{
+		Actor:      actor,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Before:     before,
+		After:      after,
+	})
+	return nil
+}
+
+func (r memoryRecorder) Webhook(userID, kind string, data interface{}) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.state.webhooks = append(r.s.state.webhooks, WebhookEntry{UserID: userID, Kind: kind, Data: data})
+	return nil
+}
+
+func (r memoryRecorder) StageTransition(company models.Company, from, note string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.state.stageTransitions = append(r.s.state.stageTransitions, models.CompanyStageTransition{
+		ID:             newID(),
+		CompanyID:      company.ID,
+		UserID:         company.UserID,
+		FromStage:      from,
+		ToStage:        company.CurrentStage,
+		Note:           note,
+		TransitionedAt: r.s.Now(),
+	})
+	return nil
+}
+
+func (r memoryRecorder) SyncReminders(event models.Event, defaults jobs.ReminderDefaults, recipient string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.state.reminderSyncs = append(r.s.state.reminderSyncs, ReminderSync{Event: event, Defaults: defaults, Recipient: recipient})
+	return nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"career-schedule-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SortKey 並び替えに使える列（式）
+type SortKey struct {
+	Expr string
+	Time bool // 値が時刻かどうか（カーソルの復元に使う）
+}
+
+// Cursor 最後に返した行の並び替えキーとID（キーセットページネーション）
+type Cursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// Page 一覧の並び替えと取得範囲
+// SortName が空なら並び順は保証せず、Limit が0以下なら件数を制限しない
+type Page struct {
+	SortName string
+	Sort     SortKey
+	Desc     bool
+	Limit    int
+	Cursor   *Cursor
+}
+
+// CompanySortKeys 企業一覧で指定できる並び替えキー
+var CompanySortKeys = map[string]SortKey{
+	"updated_at": {Expr: "updated_at", Time: true},
+	"created_at": {Expr: "created_at", Time: true},
+	"name":       {Expr: "name"},
+}
+
+// EventSortKeys 予定一覧で指定できる並び替えキー
+// confirmed_start は未確定の予定を 1970-01-01 として扱う
+var EventSortKeys = map[string]SortKey{
+	"created_at":      {Expr: "created_at", Time: true},
+	"updated_at":      {Expr: "updated_at", Time: true},
+	"title":           {Expr: "title"},
+	"confirmed_start": {Expr: "COALESCE(" + models.ConfirmedStartExpr + ", '1970-01-01T00:00:00Z'::timestamptz)", Time: true},
+}
+
+// CompanySortValue 企業の並び替えキーの値（time.Time または string）
+func CompanySortValue(company models.Company, sortName string) interface{} {
+	switch sortName {
+	case "created_at":
+		return company.CreatedAt
+	case "name":
+		return company.Name
+	default:
+		return company.UpdatedAt
+	}
+}
+
+// EventSortValue 予定の並び替えキーの値（time.Time または string）
+func EventSortValue(event models.Event, sortName string) interface{} {
+	switch sortName {
+	case "updated_at":
+		return event.UpdatedAt
+	case "title":
+		return event.Title
+	case "confirmed_start":
+		if start, ok := confirmedStart(event); ok {
+			return start
+		}
+		return time.Unix(0, 0)
+	default:
+		return event.CreatedAt
+	}
+}
+
+// confirmedStart 確定日時の開始（未確定・不正な値なら false）
+func confirmedStart(event models.Event) (time.Time, bool) {
+	var confirmed struct {
+		StartTime time.Time `json:"start_time"`
+	}
+	if len(event.ConfirmedSlot) == 0 || json.Unmarshal(event.ConfirmedSlot, &confirmed) != nil || confirmed.StartTime.IsZero() {
+		return time.Time{}, false
+	}
+	return confirmed.StartTime, true
+}
+
+// OrderClause ORDER BY 句（同値の場合は id で順序を安定させる）
+func (p Page) OrderClause() string {
+	if p.Desc {
+		return p.Sort.Expr + " DESC, id DESC"
+	}
+	return p.Sort.Expr + " ASC, id ASC"
+}
+
+// cursorValue カーソルの値を並び替えキーの型に戻す
+func (p Page) cursorValue() (interface{}, error) {
+	if !p.Sort.Time {
+		return p.Cursor.Value, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, p.Cursor.Value)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+	return t, nil
+}
+
+// ApplyCursor カーソル以降の行に絞り込む
+func (p Page) ApplyCursor(query *gorm.DB) (*gorm.DB, error) {
+	if p.Cursor == nil {
+		return query, nil
+	}
+	value, err := p.cursorValue()
+	if err != nil {
+		return nil, err
+	}
+
+	op := ">"
+	if p.Desc {
+		op = "<"
+	}
+	return query.Where("("+p.Sort.Expr+", id) "+op+" (?, ?)", value, p.Cursor.ID), nil
+}
+
+// apply 並び替え・カーソル・件数をクエリに適用する
+func (p Page) apply(query *gorm.DB) (*gorm.DB, error) {
+	if p.SortName != "" {
+		var err error
+		if query, err = p.ApplyCursor(query); err != nil {
+			return nil, err
+		}
+		query = query.Order(p.OrderClause())
+	}
+	if p.Limit > 0 {
+		query = query.Limit(p.Limit)
+	}
+	return query, nil
+}
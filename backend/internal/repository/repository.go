@@ -0,0 +1,86 @@
+// Package repository は企業・予定の永続化を扱う
+// ハンドラーはここのインターフェースだけを使い、本番では PostgreSQL（GORM）、テストではメモリ上の実装を渡す
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"career-schedule-api/internal/audit"
+	"career-schedule-api/internal/jobs"
+	"career-schedule-api/internal/models"
+)
+
+// ErrNotFound ユーザーの企業・予定（ゴミ箱にないもの）が見つからない
+var ErrNotFound = errors.New("record not found")
+
+// CompanyFilter 企業一覧の絞り込み（空の項目は絞り込まない）
+type CompanyFilter struct {
+	IsArchived   *bool
+	CurrentStage string
+	Industry     string
+}
+
+// EventFilter 予定一覧の絞り込み（空の項目は絞り込まない）
+// From / To は確定日時の開始で絞り込み、指定した場合は未確定の予定を含まない
+type EventFilter struct {
+	IsArchived *bool
+	ArchivedAt *time.Time
+	Status     string
+	Type       string
+	CompanyID  string
+	From       *time.Time
+	To         *time.Time
+}
+
+// CompanyRepository 企業の読み書き。すべての操作はユーザーで絞り込み、ゴミ箱の企業は対象外
+type CompanyRepository interface {
+	List(userID string, filter CompanyFilter, page Page) ([]models.Company, error)
+	Count(userID string, filter CompanyFilter) (int64, error)
+	Get(userID, id string) (models.Company, error)
+	// Create company.UserID のユーザーの企業として登録し、ID などを設定する
+	Create(company *models.Company) error
+	// Save 既存の企業を上書きする（company.UserID の企業でなければ ErrNotFound）
+	Save(company *models.Company) error
+	// Delete ゴミ箱に移動し、移動後の企業を返す
+	Delete(userID, id string) (models.Company, error)
+}
+
+// EventRepository 予定の読み書き。すべての操作はユーザーで絞り込み、ゴミ箱の予定は対象外
+type EventRepository interface {
+	List(userID string, filter EventFilter, page Page) ([]models.Event, error)
+	Count(userID string, filter EventFilter) (int64, error)
+	Get(userID, id string) (models.Event, error)
+	// Create event.UserID のユーザーの予定として登録し、ID などを設定する
+	Create(event *models.Event) error
+	// Save 既存の予定を上書きする（event.UserID の予定でなければ ErrNotFound）
+	Save(event *models.Event) error
+	// Delete ゴミ箱に移動し、移動後の予定を返す
+	Delete(userID, id string) (models.Event, error)
+	// AutoArchive 確定・キャンセル済みの予定を自動アーカイブし、件数を返す（基準は jobs.ArchiveEvents）
+	// アーカイブした予定ごとに actor の操作として監査ログを記録する
+	AutoArchive(userID string, actor audit.Actor) (int64, error)
+}
+
+// Recorder 企業・予定の変更に付随して、同じトランザクションで記録するもの
+type Recorder interface {
+	Audit(actor audit.Actor, entityType, entityID, action string, before, after interface{}) error
+	Webhook(userID, kind string, data interface{}) error
+	// StageTransition company の現在のステージへの変更を履歴に追加（from が空なら初期ステージ）
+	StageTransition(company models.Company, from, note string) error
+	// SyncReminders 予定の確定日時に合わせてリマインダーを作成・再計算する（jobs.SyncReminders）
+	SyncReminders(event models.Event, defaults jobs.ReminderDefaults, recipient string) error
+}
+
+// Store 企業・予定のハンドラーが使うリポジトリと記録をまとめたもの
+type Store interface {
+	Companies() CompanyRepository
+	Events() EventRepository
+	Recorder() Recorder
+	// UserSettings ユーザー設定（未保存の場合はデフォルト値）
+	UserSettings(userID string) (models.UserSettings, error)
+	// CheckEmailTemplate テンプレートがユーザーのものか確認する（無ければ ErrNotFound）
+	CheckEmailTemplate(userID, templateID string) error
+	// Transaction fn に渡す Store の操作をすべて1つのトランザクションで行う（fn がエラーを返すと取り消す）
+	Transaction(fn func(tx Store) error) error
+}
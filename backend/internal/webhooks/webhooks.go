@@ -0,0 +1,107 @@
+// Package webhooks はユーザーが登録した Webhook への変更通知（アウトボックスへの登録と署名）を扱う
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"career-schedule-api/internal/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// 購読できる変更の種別
+const (
+	CompanyCreated      = "company.created"
+	CompanyUpdated      = "company.updated"
+	CompanyStageChanged = "company.stage_changed"
+	CompanyArchived     = "company.archived"
+	CompanyDeleted      = "company.deleted"
+	CompanyRestored     = "company.restored"
+	EventCreated        = "event.created"
+	EventUpdated        = "event.updated"
+	EventConfirmed      = "event.confirmed"
+	EventArchived       = "event.archived"
+	EventDeleted        = "event.deleted"
+	EventRestored       = "event.restored"
+)
+
+// Kinds 購読できる種別の一覧
+var Kinds = []string{
+	CompanyCreated, CompanyUpdated, CompanyStageChanged, CompanyArchived, CompanyDeleted, CompanyRestored,
+	EventCreated, EventUpdated, EventConfirmed, EventArchived, EventDeleted, EventRestored,
+}
+
+// ValidKind 購読できる種別かどうか
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// 送信時のヘッダー
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Envelope 送信する JSON 本体
+type Envelope struct {
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Enqueue ユーザーの有効な Webhook のうち kind を購読しているものへの送信をアウトボックスに積む
+// 変更と同じトランザクション（tx）で呼び、変更がロールバックされた場合は送信もしない
+func Enqueue(tx *gorm.DB, userID, kind string, data interface{}) error {
+	var hooks []models.Webhook
+	subscribed, _ := json.Marshal([]string{kind})
+	if err := tx.Select("id").
+		Where("user_id = ? AND is_active = ? AND events @> CAST(? AS jsonb)", userID, true, string(subscribed)).
+		Find(&hooks).Error; err != nil {
+		return err
+	}
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(Envelope{Type: kind, OccurredAt: now, Data: data})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(hooks))
+	for _, hook := range hooks {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     hook.ID,
+			UserID:        userID,
+			EventKind:     kind,
+			Payload:       datatypes.JSON(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	return tx.Create(&deliveries).Error
+}
+
+// Sign 署名 "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+// 受信側は X-Webhook-Timestamp と本文から同じ値を計算して照合する
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
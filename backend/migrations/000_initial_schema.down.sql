@@ -0,0 +1,13 @@
+-- 初期スキーマの削除（すべてのデータが失われる）
+
+DROP TABLE IF EXISTS api_tokens;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS reminder_deliveries;
+DROP TABLE IF EXISTS reminders;
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS email_templates;
+DROP TABLE IF EXISTS calendar_feed_tokens;
+DROP TABLE IF EXISTS user_settings;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS companies;
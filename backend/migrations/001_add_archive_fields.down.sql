@@ -0,0 +1,13 @@
+-- アーカイブ関連フィールドの削除
+
+DROP INDEX IF EXISTS idx_events_archived_at;
+DROP INDEX IF EXISTS idx_companies_archived_at;
+DROP INDEX IF EXISTS idx_events_is_archived;
+DROP INDEX IF EXISTS idx_companies_is_archived;
+DROP INDEX IF EXISTS idx_events_user_archived;
+DROP INDEX IF EXISTS idx_companies_user_archived;
+
+ALTER TABLE events DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE events DROP COLUMN IF EXISTS is_archived;
+ALTER TABLE companies DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE companies DROP COLUMN IF EXISTS is_archived;
@@ -1,7 +1,7 @@
 -- アーカイブ機能のためのフィールド追加マイグレーション
 -- 実行日時: 2024-01-XX
 -- 説明: 企業とイベントテーブルにアーカイブ関連フィールドを追加
--- Supabase用: DashboardのSQL Editorで実行してください
+-- サーバー起動時（または `server migrate up`）に自動で適用される
 
 -- 企業テーブルにアーカイブフィールドを追加
 ALTER TABLE companies 
@@ -26,6 +26,13 @@ ON companies(user_id, is_archived);
 CREATE INDEX IF NOT EXISTS idx_events_user_archived 
 ON events(user_id, is_archived);
 
+-- アーカイブ状態のインデックス（GORM の命名に合わせる）
+CREATE INDEX IF NOT EXISTS idx_companies_is_archived
+ON companies(is_archived);
+
+CREATE INDEX IF NOT EXISTS idx_events_is_archived
+ON events(is_archived);
+
 -- アーカイブ日時のインデックス（企業）
 CREATE INDEX IF NOT EXISTS idx_companies_archived_at 
 ON companies(archived_at) WHERE archived_at IS NOT NULL;
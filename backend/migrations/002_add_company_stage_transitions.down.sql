@@ -0,0 +1,3 @@
+-- 選考ステージ履歴テーブルの削除
+
+DROP TABLE IF EXISTS company_stage_transitions;
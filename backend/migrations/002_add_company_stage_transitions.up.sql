@@ -0,0 +1,31 @@
+-- 選考ステージ履歴テーブルの追加マイグレーション
+-- 説明: 企業の選考ステージ変更を記録するテーブルを追加し、既存企業の現在ステージを初期履歴として登録
+-- サーバー起動時（または `server migrate up`）に自動で適用される
+
+CREATE TABLE IF NOT EXISTS company_stage_transitions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    from_stage TEXT,
+    to_stage TEXT NOT NULL,
+    note TEXT,
+    transitioned_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_company_stage_transitions_company_id
+ON company_stage_transitions(company_id);
+
+CREATE INDEX IF NOT EXISTS idx_company_stage_transitions_user_id
+ON company_stage_transitions(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_company_stage_transitions_transitioned_at
+ON company_stage_transitions(transitioned_at);
+
+-- 既存企業の補完: 履歴が1件もない企業は、登録日時（無ければ適用時）に現在のステージへ遷移したものとして記録
+-- 何度実行しても重複しない
+INSERT INTO company_stage_transitions (company_id, user_id, from_stage, to_stage, note, transitioned_at)
+SELECT c.id, c.user_id, '', c.current_stage, '', COALESCE(c.created_at, now())
+FROM companies c
+WHERE NOT EXISTS (
+    SELECT 1 FROM company_stage_transitions t WHERE t.company_id = c.id
+);
@@ -0,0 +1,11 @@
+-- 全文検索用インデックスの削除（pg_trgm 拡張は他で使われている可能性があるため残す）
+
+DROP INDEX IF EXISTS idx_events_notes_trgm;
+DROP INDEX IF EXISTS idx_events_location_trgm;
+DROP INDEX IF EXISTS idx_events_title_trgm;
+DROP INDEX IF EXISTS idx_companies_notes_trgm;
+DROP INDEX IF EXISTS idx_companies_position_trgm;
+DROP INDEX IF EXISTS idx_companies_industry_trgm;
+DROP INDEX IF EXISTS idx_companies_name_trgm;
+DROP INDEX IF EXISTS idx_events_search_tsv;
+DROP INDEX IF EXISTS idx_companies_search_tsv;
@@ -0,0 +1,24 @@
+-- 全文検索のためのインデックス追加マイグレーション
+-- 説明: GET /api/v1/search で使う全文検索（simple 構成）とトライグラム（部分一致）用のインデックスを追加
+-- サーバー起動時（または `server migrate up`）に自動で適用される
+
+-- 日本語は単語分割されないため、部分一致（ILIKE）をトライグラムインデックスで高速化する
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 全文検索用の式インデックス（internal/handlers/search.go の式と一致させること）
+CREATE INDEX IF NOT EXISTS idx_companies_search_tsv
+ON companies USING gin (to_tsvector('simple', coalesce(name, '') || ' ' || coalesce(industry, '') || ' ' || coalesce(position, '') || ' ' || coalesce(notes, '')));
+
+CREATE INDEX IF NOT EXISTS idx_events_search_tsv
+ON events USING gin (to_tsvector('simple', coalesce(title, '') || ' ' || coalesce(location, '') || ' ' || coalesce(notes, '')));
+
+-- 部分一致用のトライグラムインデックス（企業）
+CREATE INDEX IF NOT EXISTS idx_companies_name_trgm ON companies USING gin (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_companies_industry_trgm ON companies USING gin (industry gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_companies_position_trgm ON companies USING gin (position gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_companies_notes_trgm ON companies USING gin (notes gin_trgm_ops);
+
+-- 部分一致用のトライグラムインデックス（イベント）
+CREATE INDEX IF NOT EXISTS idx_events_title_trgm ON events USING gin (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_events_location_trgm ON events USING gin (location gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_events_notes_trgm ON events USING gin (notes gin_trgm_ops);
@@ -0,0 +1,4 @@
+-- 監査ログテーブルの削除
+
+DROP TABLE IF EXISTS audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_append_only();
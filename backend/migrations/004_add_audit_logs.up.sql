@@ -0,0 +1,33 @@
+-- 監査ログテーブルの追加マイグレーション
+-- 説明: 企業・予定などの作成・更新・削除・アーカイブ・確定を記録するテーブルを追加し、更新・削除をトリガーで禁止
+-- サーバー起動時（または `server migrate up`）に自動で適用される
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    changes JSONB NOT NULL,
+    request_id TEXT,
+    client_ip TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_created
+ON audit_logs(user_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity_id
+ON audit_logs(entity_id);
+
+-- 追記のみ: UPDATE / DELETE は例外にする
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only
+BEFORE UPDATE OR DELETE ON audit_logs
+FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
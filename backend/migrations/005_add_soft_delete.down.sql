@@ -0,0 +1,8 @@
+-- ゴミ箱（論理削除）フィールドの削除
+-- 注意: ゴミ箱にある企業・予定は削除されずに元に戻るため、先に完全削除しておくこと
+
+DROP INDEX IF EXISTS idx_events_deleted_at;
+DROP INDEX IF EXISTS idx_companies_deleted_at;
+
+ALTER TABLE events DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE companies DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,16 @@
+-- ゴミ箱（論理削除）のためのフィールド追加マイグレーション
+-- 説明: 企業とイベントテーブルに削除日時を追加（削除APIは行を消さずに deleted_at を設定し、保持期間を過ぎたものを定期ジョブで完全に削除）
+-- サーバー起動時（または `server migrate up`）に自動で適用される
+
+ALTER TABLE companies
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE events
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- GORM の命名に合わせたインデックス（ゴミ箱一覧と完全削除の対象検索に使用）
+CREATE INDEX IF NOT EXISTS idx_companies_deleted_at
+ON companies(deleted_at);
+
+CREATE INDEX IF NOT EXISTS idx_events_deleted_at
+ON events(deleted_at);
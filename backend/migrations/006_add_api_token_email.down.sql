@@ -0,0 +1,3 @@
+-- 個人用アクセストークンのメールアドレスの削除
+
+ALTER TABLE api_tokens DROP COLUMN IF EXISTS email;
@@ -0,0 +1,7 @@
+-- 個人用アクセストークンにメールアドレスを追加するマイグレーション
+-- 説明: トークンでの認証時に user_email を設定し、メールのリマインダーなどがログ出力に切り替わらないようにする
+-- 発行時のログインセッションのメールアドレスを保存する（このマイグレーションより前に発行したトークンは空のまま。再発行すると設定される）
+-- サーバー起動時（または `server migrate up`）に自動で適用される
+
+ALTER TABLE api_tokens
+ADD COLUMN IF NOT EXISTS email TEXT NOT NULL DEFAULT '';
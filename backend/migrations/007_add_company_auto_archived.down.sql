@@ -0,0 +1,3 @@
+-- 企業の自動アーカイブのフラグの削除
+
+ALTER TABLE companies DROP COLUMN IF EXISTS auto_archived;
@@ -0,0 +1,7 @@
+-- 企業の自動アーカイブのフラグを追加するマイグレーション
+-- 説明: 不合格による自動アーカイブかどうかを記録し、選考の再開時は自動アーカイブのみ解除する（手動でのアーカイブはそのまま）
+-- 既存のアーカイブ済みの企業は手動でのアーカイブとして扱う（再開しても解除しない）
+-- サーバー起動時（または `server migrate up`）に自動で適用される
+
+ALTER TABLE companies
+ADD COLUMN IF NOT EXISTS auto_archived BOOLEAN NOT NULL DEFAULT FALSE;
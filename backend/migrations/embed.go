@@ -0,0 +1,8 @@
+// Package migrations はデータベースのマイグレーション（NNN_name.up.sql / NNN_name.down.sql）をバイナリに埋め込む
+// 適用は internal/migrate が行う。一度リリースしたファイルは変更せず、新しい番号のファイルを追加すること
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS